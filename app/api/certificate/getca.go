@@ -0,0 +1,23 @@
+package certificate
+
+import (
+	"net/http"
+	"zinktray/app/api/context"
+)
+
+// GetCaCertificateHandler creates handler for trusted certificate retrieval API.
+//
+// Returns PEM-encoded certificate mail clients are expected to trust: either generated CA certificate, or configured
+// server certificate chain. Returns HTTP 404 Not Found when TLS is not enabled.
+func GetCaCertificateHandler(context *context.RequestHandlerContext) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		if context.Certificate == nil {
+			response.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		response.Header().Add("Content-Type", "application/x-pem-file")
+		response.Header().Add("Content-Disposition", `attachment; filename="zinktray-ca.pem"`)
+		response.Write(context.Certificate.TrustPEM)
+	}
+}
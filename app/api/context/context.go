@@ -1,9 +1,21 @@
 package context
 
 import (
+	"zinktray/app/certificate"
+	"zinktray/app/metrics"
+	"zinktray/app/relay"
 	"zinktray/app/storage"
 )
 
 type RequestHandlerContext struct {
-	Store *storage.Storage
+	Store storage.Storage
+
+	// Certificate contains TLS certificate served by mail servers. nil when TLS is not enabled.
+	Certificate *certificate.Bundle
+
+	// Relay provides upstream SMTP relay stored messages are released to.
+	Relay *relay.Relay
+
+	// Metrics collects counters exposed by metrics retrieval API.
+	Metrics *metrics.Metrics
 }
@@ -0,0 +1,8 @@
+package event
+
+// eventInfo describes storage event to be exposed through HTTP API.
+type eventInfo struct {
+	Type      string `json:"type"`
+	MailboxID string `json:"mailboxId"`
+	MessageID string `json:"messageId,omitempty"`
+}
@@ -0,0 +1,94 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"zinktray/app/api/context"
+)
+
+// keepAliveInterval contains interval of sending comments to keep idle connections open.
+const keepAliveInterval = 15 * time.Second
+
+// subscriptionBufferSize contains number of events buffered for a single client.
+const subscriptionBufferSize = 64
+
+// resyncEventType contains type of event sent once storage events have been dropped for a client that falls behind.
+const resyncEventType = "resync"
+
+// StreamEventsHandler creates handler for storage event streaming API.
+//
+// Streams storage events (mailbox.added, mailbox.deleted, message.added, message.deleted) as Server-Sent Events
+// until client disconnects. Event name matches event type, event data contains JSON-encoded event information.
+//
+// Sends "resync" event once events have been dropped because client falls behind, so that it could reload the state.
+//
+// Accepts optional "mailbox_id" form parameter to stream only events related to that mailbox.
+func StreamEventsHandler(context *context.RequestHandlerContext) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		flusher, ok := response.(http.Flusher)
+
+		if !ok {
+			log.Println("Event streaming is not supported by response writer")
+
+			response.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		mailboxId := request.FormValue("mailbox_id")
+		subscription := context.Store.Subscribe(subscriptionBufferSize)
+
+		defer subscription.Close()
+
+		response.Header().Add("Content-Type", "text/event-stream")
+		response.Header().Add("Cache-Control", "no-cache")
+		response.Header().Add("X-Accel-Buffering", "no")
+		response.WriteHeader(http.StatusOK)
+
+		// Let client know the stream is open before any event happens.
+		fmt.Fprint(response, ": connected\n\n")
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-request.Context().Done():
+				return
+			case <-keepAlive.C:
+				fmt.Fprint(response, ": keep-alive\n\n")
+			case <-subscription.Dropped:
+				fmt.Fprintf(response, "event: %s\ndata: {\"type\":\"%s\"}\n\n", resyncEventType, resyncEventType)
+			case event, ok := <-subscription.Events:
+				if !ok {
+					return
+				}
+
+				if mailboxId != "" && event.MailboxID != mailboxId {
+					continue
+				}
+
+				encoded, err := json.Marshal(eventInfo{
+					Type:      string(event.Type),
+					MailboxID: event.MailboxID,
+					MessageID: event.MessageID,
+				})
+
+				if err != nil {
+					log.Printf("Cannot encode event: %s\n", err)
+
+					continue
+				}
+
+				fmt.Fprintf(response, "event: %s\ndata: %s\n\n", event.Type, encoded)
+			}
+
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package event
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"zinktray/app/api/context"
+	"zinktray/app/storage"
+)
+
+func TestStreamEventsResync(t *testing.T) {
+	var store = &slowStorage{MemoryStorage: storage.NewMemoryStorage()}
+	var server = httptest.NewServer(StreamEventsHandler(&context.RequestHandlerContext{Store: store}))
+
+	t.Cleanup(server.Close)
+
+	response, err := http.Get(server.URL)
+
+	if err != nil {
+		t.Fatalf("Cannot stream events: %s", err)
+	}
+
+	defer response.Body.Close()
+
+	var scanner = bufio.NewScanner(response.Body)
+
+	// Stream is reported open once subscribed.
+	if !scanner.Scan() || scanner.Text() != ": connected" {
+		t.Fatalf("Unexpected stream start: %q", scanner.Text())
+	}
+
+	// Messages overflow subscription buffer, so that events are dropped.
+	for i := 0; i < 100; i++ {
+		if _, err := storage.Deliver(store, "flood", fmt.Sprintf("Subject: Flood %d\r\n\r\nHi", i), nil); err != nil {
+			t.Fatalf("Cannot deliver message: %s", err)
+		}
+	}
+
+	for scanner.Scan() {
+		if scanner.Text() == "event: resync" {
+			if !scanner.Scan() || scanner.Text() != "data: {\"type\":\"resync\"}" {
+				t.Errorf("Unexpected resync event data: %q", scanner.Text())
+			}
+
+			return
+		}
+	}
+
+	t.Fatalf("Resync event is expected once events are dropped: %v", scanner.Err())
+}
+
+// slowStorage represents storage with the smallest event buffer possible.
+type slowStorage struct {
+	*storage.MemoryStorage
+}
+
+func (store *slowStorage) Subscribe(bufferSize int) *storage.Subscription {
+	return store.MemoryStorage.Subscribe(1)
+}
@@ -0,0 +1,54 @@
+package list
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"zinktray/app/storage"
+)
+
+// ParseOptions extracts list options out of "sort", "limit" and "cursor" form parameters.
+//
+// Returns an error for malformed or negative limit.
+func ParseOptions(request *http.Request) (storage.ListOptions, error) {
+	options := storage.ListOptions{
+		Order:  storage.Order(request.FormValue("sort")),
+		Cursor: request.FormValue("cursor"),
+	}
+
+	if limit := request.FormValue("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+
+		if err != nil || value < 0 {
+			return options, fmt.Errorf("invalid limit %q", limit)
+		}
+
+		options.Limit = value
+	}
+
+	return options, nil
+}
+
+// ErrorStatus returns HTTP status code corresponding to an error of listing storage contents.
+//
+// Invalid sort order and cursor stand for HTTP 400 Bad Request, any other error stands for HTTP 500 Internal Server
+// Error.
+func ErrorStatus(err error) int {
+	if errors.Is(err, storage.ErrInvalidOrder) || errors.Is(err, storage.ErrInvalidCursor) {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
+
+// NextCursor converts cursor of the next page into its HTTP API representation.
+//
+// Returns nil for the last page.
+func NextCursor(cursor string) *string {
+	if cursor == "" {
+		return nil
+	}
+
+	return &cursor
+}
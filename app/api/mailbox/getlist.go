@@ -5,14 +5,42 @@ import (
 	"log"
 	"net/http"
 	"zinktray/app/api/context"
+	"zinktray/app/api/list"
 )
 
+// GetMailboxListHandler creates handler for mailbox list retrieval API.
+//
+// Mailboxes are listed in order of registration unless "sort" form parameter specifies otherwise ("oldest", "newest"
+// or "id"). Accepts "contains" form parameter to list only mailboxes with IDs containing it, and "limit" and "cursor"
+// form parameters for pagination. Returns HTTP 400 Bad Request for malformed parameters.
 func GetMailboxListHandler(context *context.RequestHandlerContext) http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
-		publishList := make([]essentialMailboxInfo, 0, context.Store.CountMailboxes())
+		options, err := list.ParseOptions(request)
 
-		for _, mbx := range context.Store.GetMailboxes() {
-			publishList = append(publishList, essentialMailboxInfo{
+		if err != nil {
+			log.Printf("Malformed list options: %s\n", err)
+
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		page, err := context.Store.ListMailboxes(request.FormValue("contains"), options)
+
+		if err != nil {
+			log.Printf("Cannot list mailboxes: %s\n", err)
+
+			writer.WriteHeader(list.ErrorStatus(err))
+			return
+		}
+
+		publishList := mailboxList{
+			Total:      page.Total,
+			NextCursor: list.NextCursor(page.NextCursor),
+			Mailboxes:  make([]essentialMailboxInfo, 0, len(page.Mailboxes)),
+		}
+
+		for _, mbx := range page.Mailboxes {
+			publishList.Mailboxes = append(publishList.Mailboxes, essentialMailboxInfo{
 				ID: mbx.ID,
 			})
 		}
@@ -4,3 +4,10 @@ package mailbox
 type essentialMailboxInfo struct {
 	ID string `json:"id"`
 }
+
+// mailboxList describes a page of mailbox list to be exposed through HTTP API.
+type mailboxList struct {
+	Total      int                    `json:"total"`
+	NextCursor *string                `json:"nextCursor"`
+	Mailboxes  []essentialMailboxInfo `json:"mailboxes"`
+}
@@ -0,0 +1,154 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"zinktray/app/api/context"
+	"zinktray/app/message/compose"
+	"zinktray/app/storage"
+)
+
+// maxAddedMessageBytes contains maximum size of message injection request body.
+const maxAddedMessageBytes = 32 * 1024 * 1024
+
+// newMessage describes structured message to be composed through HTTP API.
+type newMessage struct {
+	From        string          `json:"from"`
+	To          []string        `json:"to"`
+	Cc          []string        `json:"cc"`
+	Subject     string          `json:"subject"`
+	Text        string          `json:"text"`
+	Html        string          `json:"html"`
+	Attachments []newAttachment `json:"attachments"`
+}
+
+// newAttachment describes attachment of a message to be composed through HTTP API.
+type newAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Data        []byte `json:"data"`
+}
+
+// addedMessage describes message stored through HTTP API.
+type addedMessage struct {
+	ID string `json:"id"`
+}
+
+// AddMessageHandler creates handler for message injection API.
+//
+// Request body contains either raw RFC 5322 message, or JSON-encoded message description when "application/json"
+// content type is given. Message is stored into mailbox given by "mailbox_id" query parameter, which is registered
+// if necessary. Responds with HTTP 201 Created and JSON-encoded ID of stored message.
+//
+// Returns HTTP 400 Bad Request for missing mailbox ID and malformed messages.
+func AddMessageHandler(context *context.RequestHandlerContext) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodPost {
+			response.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Query is used instead of form values, so that request body is never treated as form.
+		mailboxId := request.URL.Query().Get("mailbox_id")
+
+		if mailboxId == "" {
+			log.Println("Mailbox ID is not given")
+
+			response.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		body := http.MaxBytesReader(response, request.Body, maxAddedMessageBytes)
+		rawData, err := readNewMessage(request.Header.Get("Content-Type"), body)
+
+		if err != nil {
+			log.Printf("Malformed message: %s\n", err)
+
+			var maxBytesError *http.MaxBytesError
+
+			if errors.As(err, &maxBytesError) {
+				response.WriteHeader(http.StatusRequestEntityTooLarge)
+			} else {
+				response.WriteHeader(http.StatusBadRequest)
+			}
+
+			return
+		}
+
+		msg, err := storage.Deliver(context.Store, mailboxId, rawData, nil)
+
+		if err != nil {
+			log.Printf("Cannot store message: %s\n", err)
+
+			response.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if encoded, err := json.Marshal(addedMessage{ID: msg.ID}); err != nil {
+			log.Printf("Cannot encode message ID: %s\n", err)
+
+			response.WriteHeader(http.StatusInternalServerError)
+		} else {
+			response.Header().Add("content-Type", "application/json")
+			response.WriteHeader(http.StatusCreated)
+			response.Write(encoded)
+		}
+	}
+}
+
+// readNewMessage reads raw contents of message to be stored out of request body.
+//
+// JSON-encoded message description is composed into MIME message, any other body is taken as raw message.
+func readNewMessage(contentType string, body io.Reader) (string, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	if mediaType != "application/json" {
+		data, err := io.ReadAll(body)
+
+		if err != nil {
+			return "", err
+		}
+
+		if len(bytes.TrimSpace(data)) == 0 {
+			return "", errors.New("message is empty")
+		}
+
+		return string(data), nil
+	}
+
+	var description newMessage
+
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&description); err != nil {
+		return "", fmt.Errorf("cannot decode message description: %w", err)
+	}
+
+	msg := &compose.Message{
+		From:    description.From,
+		To:      description.To,
+		Cc:      description.Cc,
+		Subject: description.Subject,
+		Text:    description.Text,
+		Html:    description.Html,
+	}
+
+	for _, attachment := range description.Attachments {
+		msg.Attachments = append(msg.Attachments, compose.Attachment{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Data:        attachment.Data,
+		})
+	}
+
+	return compose.Build(msg)
+}
@@ -0,0 +1,74 @@
+package message
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"zinktray/app/api/context"
+	"zinktray/app/message/parse"
+)
+
+// GetAttachmentHandler creates handler for message attachment download API.
+//
+// Responds with decoded attachment contents, using attachment media type as response content type. Content type
+// sniffing is disabled, so that browsers do not render attachments as anything else.
+//
+// Expects "message_id" and "index" form parameters, where index is zero-based attachment index as returned by detailed
+// message information retrieval API. Returns HTTP 400 Bad Request for malformed index, and HTTP 404 Not Found for
+// unknown message or attachment.
+func GetAttachmentHandler(context *context.RequestHandlerContext) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		messageId := request.FormValue("message_id")
+
+		index, err := strconv.Atoi(request.FormValue("index"))
+
+		if err != nil || index < 0 {
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		msg := context.Store.GetMessage(messageId)
+
+		if msg == nil {
+			log.Printf("Message \"%s\" not found\n", messageId)
+
+			response.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		messageContent, err := parse.ReadContents(msg.GetRawData())
+
+		if err != nil {
+			log.Printf("Cannot extract message content: %s\n", err)
+
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if index >= len(messageContent.Attachments) {
+			response.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		attachment := messageContent.Attachments[index]
+		filename := attachment.Filename
+
+		if filename == "" {
+			filename = fmt.Sprintf("attachment-%d", index)
+		}
+
+		response.Header().Add("Content-Type", attachment.ContentType)
+		response.Header().Add("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+		response.Header().Add("Content-Length", strconv.Itoa(len(attachment.Data)))
+		response.Header().Add("X-Content-Type-Options", "nosniff")
+		response.Write(attachment.Data)
+	}
+}
+
+// attachmentURL returns attachment download API URL of message attachment with provided index.
+func attachmentURL(messageID string, index int) string {
+	return fmt.Sprintf("/api/messages/attachment?message_id=%s&index=%d", url.QueryEscape(messageID), index)
+}
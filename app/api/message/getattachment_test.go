@@ -0,0 +1,74 @@
+package message
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"zinktray/app/api/context"
+	"zinktray/app/storage"
+)
+
+func TestGetAttachment(t *testing.T) {
+	var store = storage.NewMemoryStorage()
+	var handlerContext = &context.RequestHandlerContext{Store: store}
+
+	msg, err := storage.Deliver(store, "bob", "Subject: Invoice\r\n"+
+		"Content-Type: multipart/mixed; boundary=B\r\n"+
+		"\r\n"+
+		"--B\r\n"+
+		"Content-Type: text/plain\r\n"+
+		"\r\n"+
+		"See attached.\r\n"+
+		"--B\r\n"+
+		"Content-Type: text/html\r\n"+
+		"Content-Disposition: attachment; filename=invoice.html\r\n"+
+		"\r\n"+
+		"<p>Total: 42</p>\r\n"+
+		"--B--\r\n", nil)
+
+	if err != nil {
+		t.Fatalf("Cannot deliver message: %s", err)
+	}
+
+	var recorder = httptest.NewRecorder()
+
+	GetMessageDetailsHandler(handlerContext)(
+		recorder,
+		httptest.NewRequest(http.MethodGet, "/api/messages/details?message_id="+msg.ID, nil),
+	)
+
+	var details struct {
+		Attachments []map[string]any `json:"attachments"`
+	}
+
+	if err := json.NewDecoder(recorder.Body).Decode(&details); err != nil {
+		t.Fatalf("Cannot decode message details: %s", err)
+	}
+
+	if len(details.Attachments) != 1 {
+		t.Fatalf("Unexpected attachments: %v", details.Attachments)
+	}
+
+	if _, ok := details.Attachments[0]["data"]; ok {
+		t.Errorf("Attachment contents are not expected to be inlined: %v", details.Attachments[0])
+	}
+
+	var attachmentURL, _ = details.Attachments[0]["url"].(string)
+
+	if expected := "/api/messages/attachment?message_id=" + msg.ID + "&index=0"; attachmentURL != expected {
+		t.Fatalf("Unexpected attachment URL: got %q, expected %q", attachmentURL, expected)
+	}
+
+	recorder = httptest.NewRecorder()
+
+	GetAttachmentHandler(handlerContext)(recorder, httptest.NewRequest(http.MethodGet, attachmentURL, nil))
+
+	if body := recorder.Body.String(); recorder.Code != http.StatusOK || body != "<p>Total: 42</p>" {
+		t.Errorf("Unexpected attachment: %d %q", recorder.Code, body)
+	}
+
+	if header := recorder.Header().Get("X-Content-Type-Options"); header != "nosniff" {
+		t.Errorf("Content type sniffing is expected to be disabled, got %q", header)
+	}
+}
@@ -2,9 +2,11 @@ package message
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"zinktray/app/api/context"
+	message2 "zinktray/app/message"
 	"zinktray/app/message/parse"
 )
 
@@ -25,47 +27,58 @@ func GetMessageDetailsHandler(context *context.RequestHandlerContext) http.Handl
 
 			response.WriteHeader(http.StatusNotFound)
 		} else {
-			var messageInfo *parse.BasicInfo
-			var messageContent *parse.ContentInfo
-			var err error
-
-			messageInfo, err = parse.ReadBasic(msg.GetRawData())
+			writeMessageDetails(response, msg)
+		}
+	}
+}
 
-			if err != nil {
-				log.Printf("Cannot extract basic message info: %s\n", err)
+// writeMessageDetails writes JSON-encoded detailed message information as a response.
+func writeMessageDetails(response http.ResponseWriter, msg *message2.Message) {
+	publishInfo, err := newDetailedMessageInfo(msg)
 
-				response.WriteHeader(http.StatusInternalServerError)
-			}
+	if err != nil {
+		log.Println(err)
 
-			messageContent, err = parse.ReadContents(msg.GetRawData())
+		response.WriteHeader(http.StatusInternalServerError)
 
-			if err != nil {
-				log.Printf("Cannot extract message content: %s\n", err)
+		return
+	}
 
-				response.WriteHeader(http.StatusInternalServerError)
-			}
+	if encoded, err := json.Marshal(publishInfo); err != nil {
+		log.Printf("Cannot encode message: %s\n", err)
 
-			publishInfo := detailedMessageInfo{
-				ID:         msg.ID,
-				From:       messageInfo.From,
-				To:         messageInfo.To,
-				Subject:    messageInfo.Subject,
-				ReceivedAt: msg.ReceivedAt.Unix(),
-				Content: content{
-					Raw:  msg.GetRawData(),
-					Html: messageContent.Html,
-					Text: messageContent.Plain,
-				},
-			}
+		response.WriteHeader(http.StatusInternalServerError)
+	} else {
+		response.Header().Add("content-Type", "application/json")
+		response.Write(encoded)
+	}
+}
 
-			if encoded, err := json.Marshal(publishInfo); err != nil {
-				log.Printf("Cannot encode message: %s\n", err)
+// newDetailedMessageInfo extracts detailed information out of message.
+func newDetailedMessageInfo(msg *message2.Message) (*detailedMessageInfo, error) {
+	messageContent, err := parse.ReadContents(msg.GetRawData())
 
-				response.WriteHeader(http.StatusInternalServerError)
-			} else {
-				response.Header().Add("content-Type", "application/json")
-				response.Write(encoded)
-			}
-		}
+	if err != nil {
+		return nil, fmt.Errorf("cannot extract message content: %w", err)
 	}
+
+	summary := msg.GetSummary()
+
+	return &detailedMessageInfo{
+		ID:         msg.ID,
+		From:       summary.From,
+		To:         summary.To,
+		Subject:    summary.Subject,
+		RawHeaders: message2.NewRawHeadersInfo(summary),
+		ReceivedAt: msg.ReceivedAt.Unix(),
+		Envelope:   message2.NewEnvelopeInfo(msg.Envelope),
+		Content: content{
+			Raw:         msg.GetRawData(),
+			Html:        messageContent.Html,
+			HtmlCharset: messageContent.HtmlCharset,
+			Text:        messageContent.Plain,
+			TextCharset: messageContent.PlainCharset,
+		},
+		Attachments: newAttachmentInfoList(msg.ID, messageContent.Attachments),
+	}, nil
 }
@@ -0,0 +1,79 @@
+package message
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"zinktray/app/api/context"
+	"zinktray/app/message/parse"
+)
+
+// htmlContentSecurityPolicy restricts message HTML preview to inline styles and images embedded into the message.
+//
+// Scripts, forms, plugins and remote resources are not allowed, so that previewing a message never calls home.
+const htmlContentSecurityPolicy = "sandbox allow-popups allow-popups-to-escape-sandbox; default-src 'none'; " +
+	"img-src 'self' data:; style-src 'unsafe-inline'; font-src data:; base-uri 'none'; form-action 'none'"
+
+// GetMessageHtmlHandler creates handler for message HTML preview API.
+//
+// Responds with HTML content of the message. References to inline attachments ("cid:" URLs) are rewritten to point at
+// attachment download API. Response is served with restrictive Content-Security-Policy and is meant to be displayed
+// inside sandboxed frame.
+//
+// Expects "message_id" form parameter. Returns HTTP 404 Not Found for unknown message or message without HTML content.
+func GetMessageHtmlHandler(context *context.RequestHandlerContext) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		messageId := request.FormValue("message_id")
+		msg := context.Store.GetMessage(messageId)
+
+		if msg == nil {
+			log.Printf("Message \"%s\" not found\n", messageId)
+
+			response.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		messageContent, err := parse.ReadContents(msg.GetRawData())
+
+		if err != nil {
+			log.Printf("Cannot extract message content: %s\n", err)
+
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if messageContent.Html == nil {
+			response.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		response.Header().Add("Content-Type", "text/html; charset=utf-8")
+		response.Header().Add("Content-Security-Policy", htmlContentSecurityPolicy)
+		response.Header().Add("X-Content-Type-Options", "nosniff")
+		response.Header().Add("Referrer-Policy", "no-referrer")
+		response.Write([]byte(replaceContentIDs(*messageContent.Html, msg.ID, messageContent.Attachments)))
+	}
+}
+
+// replaceContentIDs rewrites "cid:" URLs referencing message attachments to attachment download API URLs.
+func replaceContentIDs(html string, messageID string, attachments []*parse.Attachment) string {
+	replacements := make([]string, 0, 2*len(attachments))
+
+	for _, attachment := range attachments {
+		if attachment.ContentID == "" {
+			continue
+		}
+
+		replacements = append(
+			replacements,
+			"cid:"+attachment.ContentID,
+			attachmentURL(messageID, attachment.Index),
+		)
+	}
+
+	if len(replacements) == 0 {
+		return html
+	}
+
+	return strings.NewReplacer(replacements...).Replace(html)
+}
@@ -5,7 +5,7 @@ import (
 	"log"
 	"net/http"
 	"zinktray/app/api/context"
-	"zinktray/app/message/parse"
+	"zinktray/app/api/list"
 )
 
 // GetMessageListHandler creates handler for message list retrieval API.
@@ -13,31 +13,43 @@ import (
 // Message list contains essential information on each message stored in provided mailbox. To retrieve message contents
 // use detailed message information retrieval API.
 //
-// Expects "mailbox_id" form parameter. Returns empty message list for unknown mailbox.
+// Essential information is taken from message summary extracted upon receiving the message. Messages which could not
+// be parsed are listed along with the parse error.
+//
+// Expects "mailbox_id" form parameter. Returns empty message list for unknown mailbox. Messages are listed newest first
+// unless "sort" form parameter specifies otherwise ("newest", "oldest", "subject" or "size"). Accepts the same filter
+// form parameters as message awaiting API, and "limit" and "cursor" form parameters for pagination. Returns HTTP 400
+// Bad Request for malformed parameters.
 func GetMessageListHandler(context *context.RequestHandlerContext) http.HandlerFunc {
 	return func(response http.ResponseWriter, request *http.Request) {
-		mailboxId := request.FormValue("mailbox_id")
-		publishList := make([]essentialMessageInfo, 0, context.Store.CountMessages(mailboxId))
-
-		for _, msg := range context.Store.GetMessages(mailboxId) {
-			if messageInfo, err := parse.ReadBasic(msg.GetRawData()); err == nil {
-				publishList = append(publishList, essentialMessageInfo{
-					ID:         msg.ID,
-					From:       messageInfo.From,
-					To:         messageInfo.To,
-					Subject:    messageInfo.Subject,
-					ReceivedAt: msg.ReceivedAt.Unix(),
-				})
-			} else {
-				log.Printf("Cannot extract basic message info: %s\n", err)
-
-				response.WriteHeader(http.StatusInternalServerError)
-
-				return
-			}
+		messageFilter, err := parseFilter(request)
+
+		if err != nil {
+			log.Printf("Malformed message filter: %s\n", err)
+
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		options, err := list.ParseOptions(request)
+
+		if err != nil {
+			log.Printf("Malformed list options: %s\n", err)
+
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		page, err := context.Store.ListMessages(request.FormValue("mailbox_id"), messageFilter, options)
+
+		if err != nil {
+			log.Printf("Cannot list messages: %s\n", err)
+
+			response.WriteHeader(list.ErrorStatus(err))
+			return
 		}
 
-		if encoded, err := json.Marshal(publishList); err != nil {
+		if encoded, err := json.Marshal(newMessageList(page)); err != nil {
 			log.Printf("Cannot encode message list: %s\n", err)
 
 			response.WriteHeader(http.StatusInternalServerError)
@@ -1,27 +1,85 @@
 package message
 
-// essentialMessageInfo describes essential information on individual message to be exposed through HTTP API.
-type essentialMessageInfo struct {
-	ID         string   `json:"id"`
-	From       []string `json:"from"`
-	To         []string `json:"to"`
-	Subject    string   `json:"subject"`
-	ReceivedAt int64    `json:"receivedAt"`
+import (
+	"zinktray/app/api/list"
+	message2 "zinktray/app/message"
+	"zinktray/app/message/parse"
+	"zinktray/app/storage"
+)
+
+// messageList describes a page of message list to be exposed through HTTP API.
+type messageList struct {
+	Total      int             `json:"total"`
+	NextCursor *string         `json:"nextCursor"`
+	Messages   []message2.Info `json:"messages"`
+}
+
+// newMessageList converts a page of message list into its HTTP API representation.
+func newMessageList(page *storage.MessagePage) messageList {
+	result := messageList{
+		Total:      page.Total,
+		NextCursor: list.NextCursor(page.NextCursor),
+		Messages:   make([]message2.Info, 0, len(page.Messages)),
+	}
+
+	for _, msg := range page.Messages {
+		result.Messages = append(result.Messages, message2.NewInfo(msg))
+	}
+
+	return result
 }
 
 // detailedMessageInfo describes full information on individual message to be exposed through HTTP API.
 type detailedMessageInfo struct {
-	ID         string   `json:"id"`
-	From       []string `json:"from"`
-	To         []string `json:"to"`
-	Subject    string   `json:"subject"`
-	ReceivedAt int64    `json:"receivedAt"`
-	Content    content  `json:"content"`
+	ID          string                  `json:"id"`
+	From        []string                `json:"from"`
+	To          []string                `json:"to"`
+	Subject     string                  `json:"subject"`
+	RawHeaders  message2.RawHeadersInfo `json:"rawHeaders"`
+	ReceivedAt  int64                   `json:"receivedAt"`
+	Envelope    *message2.EnvelopeInfo  `json:"envelope"`
+	Content     content                 `json:"content"`
+	Attachments []attachmentInfo        `json:"attachments"`
 }
 
 // content describes contents of a message to be exposed through HTTP API.
 type content struct {
-	Raw  string  `json:"raw"`
-	Html *string `json:"html"`
-	Text *string `json:"text"`
+	Raw         string  `json:"raw"`
+	Html        *string `json:"html"`
+	HtmlCharset string  `json:"htmlCharset"`
+	Text        *string `json:"text"`
+	TextCharset string  `json:"textCharset"`
+}
+
+// attachmentInfo describes message attachment to be exposed through HTTP API.
+//
+// Attachment contents are served by attachment download API only, so that large attachments do not bloat message
+// information.
+type attachmentInfo struct {
+	Index       int    `json:"index"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	ContentID   string `json:"contentId"`
+	Disposition string `json:"disposition"`
+	Size        int    `json:"size"`
+	URL         string `json:"url"`
+}
+
+// newAttachmentInfoList converts message attachments into their HTTP API representation.
+func newAttachmentInfoList(messageID string, attachments []*parse.Attachment) []attachmentInfo {
+	result := make([]attachmentInfo, 0, len(attachments))
+
+	for _, attachment := range attachments {
+		result = append(result, attachmentInfo{
+			Index:       attachment.Index,
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			ContentID:   attachment.ContentID,
+			Disposition: attachment.Disposition,
+			Size:        len(attachment.Data),
+			URL:         attachmentURL(messageID, attachment.Index),
+		})
+	}
+
+	return result
 }
@@ -0,0 +1,70 @@
+package message
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"zinktray/app/api/context"
+	"zinktray/app/relay"
+)
+
+// ReleaseMessageHandler creates handler for message release API.
+//
+// Relays stored message as is to given recipients via configured upstream SMTP relay. Expects "message_id" form
+// parameter and one or more "recipient" form parameters, each containing a single address or comma-separated list of
+// addresses.
+//
+// Returns HTTP 404 Not Found for unknown message and when message release is disabled, HTTP 400 Bad Request for
+// missing or malformed recipients, HTTP 403 Forbidden for recipients outside of allowed domains, and HTTP 502 Bad
+// Gateway when upstream relay fails.
+func ReleaseMessageHandler(context *context.RequestHandlerContext) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodPost {
+			response.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !context.Relay.IsEnabled() {
+			log.Println("Message release is disabled")
+
+			response.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		messageId := request.FormValue("message_id")
+		msg := context.Store.GetMessage(messageId)
+
+		if msg == nil {
+			log.Printf("Message \"%s\" not found\n", messageId)
+
+			response.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		var recipients []string
+
+		for _, value := range request.Form["recipient"] {
+			for _, rcpt := range strings.Split(value, ",") {
+				if rcpt = strings.TrimSpace(rcpt); rcpt != "" {
+					recipients = append(recipients, rcpt)
+				}
+			}
+		}
+
+		if err := context.Relay.Release(msg, recipients); err != nil {
+			log.Printf("Cannot release message \"%s\": %s\n", messageId, err)
+
+			switch {
+			case errors.Is(err, relay.ErrNoRecipients), errors.Is(err, relay.ErrInvalidRecipient):
+				response.WriteHeader(http.StatusBadRequest)
+			case errors.Is(err, relay.ErrRecipientNotAllowed):
+				response.WriteHeader(http.StatusForbidden)
+			default:
+				response.WriteHeader(http.StatusBadGateway)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package message
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"zinktray/app/api/context"
+	"zinktray/app/api/list"
+	"zinktray/app/search"
+)
+
+// SearchMessagesHandler creates handler for message search API.
+//
+// Responds with a list of messages satisfying search query in the same format as message list retrieval API, and
+// accepts the same "sort", "limit" and "cursor" form parameters.
+//
+// Expects "query" form parameter containing search query; see search.ParseQuery for query syntax. Searches all
+// mailboxes unless "mailbox_id" form parameter is given. Returns HTTP 400 Bad Request for malformed parameters.
+func SearchMessagesHandler(context *context.RequestHandlerContext) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		query, err := search.ParseQuery(request.FormValue("query"))
+
+		if err != nil {
+			log.Printf("Malformed search query: %s\n", err)
+
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		options, err := list.ParseOptions(request)
+
+		if err != nil {
+			log.Printf("Malformed list options: %s\n", err)
+
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		page, err := context.Store.SearchMessages(query, request.FormValue("mailbox_id"), options)
+
+		if err != nil {
+			log.Printf("Cannot search messages: %s\n", err)
+
+			response.WriteHeader(list.ErrorStatus(err))
+			return
+		}
+
+		if encoded, err := json.Marshal(newMessageList(page)); err != nil {
+			log.Printf("Cannot encode message list: %s\n", err)
+
+			response.WriteHeader(http.StatusInternalServerError)
+		} else {
+			response.Header().Add("content-Type", "application/json")
+			response.Write(encoded)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package message
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+	"zinktray/app/api/context"
+	message2 "zinktray/app/message"
+	"zinktray/app/message/filter"
+	"zinktray/app/storage"
+)
+
+// defaultWaitTimeout contains time to wait for a message when no timeout is requested.
+const defaultWaitTimeout = 30 * time.Second
+
+// maxWaitTimeout contains maximum time to wait for a message.
+const maxWaitTimeout = 5 * time.Minute
+
+// WaitMessageHandler creates handler for message awaiting API.
+//
+// Blocks until a message matching criteria is stored, then responds the same way as detailed message information
+// retrieval API. When matching message is already stored, responds immediately with the oldest one.
+//
+// Accepts optional form parameters:
+//   - "mailbox_id": mailbox message is to be stored into;
+//   - "recipient": address any of envelope recipients or "To" addresses must contain;
+//   - "from": address envelope return path or any of "From" addresses must contain;
+//   - "subject": regular expression decoded subject must match;
+//   - "after": Unix timestamp message must be received at or after;
+//   - "timeout": time to wait, either in seconds or as Go duration (e.g. "1m30s"), 30 seconds by default, 5 minutes
+//     at most.
+//
+// Returns HTTP 400 Bad Request for malformed parameters, and HTTP 204 No Content when no matching message has been
+// stored before timeout elapsed.
+func WaitMessageHandler(context *context.RequestHandlerContext) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		mailboxId := request.FormValue("mailbox_id")
+		messageFilter, err := parseFilter(request)
+
+		if err != nil {
+			log.Printf("Malformed message filter: %s\n", err)
+
+			response.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		timeout, err := parseTimeout(request.FormValue("timeout"))
+
+		if err != nil {
+			log.Printf("Malformed timeout: %s\n", err)
+
+			response.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		// Subscribe before looking through stored messages, so that no message is missed in between.
+		subscription := context.Store.Subscribe(64)
+
+		defer subscription.Close()
+
+		if msg := findStoredMessage(context.Store, mailboxId, messageFilter); msg != nil {
+			writeMessageDetails(response, msg)
+
+			return
+		}
+
+		timer := time.NewTimer(timeout)
+
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-request.Context().Done():
+				return
+			case <-timer.C:
+				response.WriteHeader(http.StatusNoContent)
+
+				return
+			case event, ok := <-subscription.Events:
+				if !ok {
+					response.WriteHeader(http.StatusNoContent)
+
+					return
+				}
+
+				if event.Type != storage.EventMessageAdded || (mailboxId != "" && event.MailboxID != mailboxId) {
+					continue
+				}
+
+				if messageFilter.Match(event.Message) {
+					writeMessageDetails(response, event.Message)
+
+					return
+				}
+			case <-subscription.Dropped:
+				// Matching message might have been among dropped events.
+				if msg := findStoredMessage(context.Store, mailboxId, messageFilter); msg != nil {
+					writeMessageDetails(response, msg)
+
+					return
+				}
+			}
+		}
+	}
+}
+
+// findStoredMessage looks for the oldest stored message matching filter.
+//
+// Looks through all mailboxes when mailboxId is empty. Returns nil when no message matches.
+func findStoredMessage(store storage.Storage, mailboxId string, messageFilter *filter.Filter) *message2.Message {
+	mailboxIds := []string{mailboxId}
+
+	if mailboxId == "" {
+		mailboxIds = mailboxIds[:0]
+
+		for _, mbx := range store.GetMailboxes() {
+			mailboxIds = append(mailboxIds, mbx.ID)
+		}
+	}
+
+	var found *message2.Message
+
+	for _, id := range mailboxIds {
+		for _, msg := range store.GetMessages(id) {
+			if (found == nil || msg.ReceivedAt.Before(found.ReceivedAt)) && messageFilter.Match(msg) {
+				found = msg
+			}
+		}
+	}
+
+	return found
+}
+
+// parseFilter builds message filter out of request parameters.
+func parseFilter(request *http.Request) (*filter.Filter, error) {
+	messageFilter := &filter.Filter{
+		Recipient: request.FormValue("recipient"),
+		From:      request.FormValue("from"),
+	}
+
+	if subject := request.FormValue("subject"); subject != "" {
+		expr, err := regexp.Compile(subject)
+
+		if err != nil {
+			return nil, err
+		}
+
+		messageFilter.Subject = expr
+	}
+
+	if after := request.FormValue("after"); after != "" {
+		timestamp, err := strconv.ParseInt(after, 10, 64)
+
+		if err != nil {
+			return nil, err
+		}
+
+		messageFilter.After = time.Unix(timestamp, 0)
+	}
+
+	return messageFilter, nil
+}
+
+// parseTimeout parses wait timeout given either in seconds or as Go duration.
+func parseTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultWaitTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+
+	if err != nil {
+		seconds, err := strconv.ParseFloat(value, 64)
+
+		if err != nil {
+			return 0, err
+		}
+
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+
+	return min(max(timeout, 0), maxWaitTimeout), nil
+}
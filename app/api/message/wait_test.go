@@ -0,0 +1,70 @@
+package message
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"zinktray/app/api/context"
+	"zinktray/app/storage"
+)
+
+func TestWaitMessageFlood(t *testing.T) {
+	var store = &slowStorage{MemoryStorage: storage.NewMemoryStorage(), subscribed: make(chan struct{})}
+	var handler = WaitMessageHandler(&context.RequestHandlerContext{Store: store})
+	var recorder = httptest.NewRecorder()
+	var done = make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		handler(recorder, httptest.NewRequest(http.MethodGet, "/api/messages/wait?subject=^Match$&timeout=5", nil))
+	}()
+
+	<-store.subscribed
+
+	// Unrelated messages overflow subscription buffer, so that events are dropped.
+	for i := 0; i < 100; i++ {
+		if _, err := storage.Deliver(store, "flood", fmt.Sprintf("Subject: Flood %d\r\n\r\nHi", i), nil); err != nil {
+			t.Fatalf("Cannot deliver message: %s", err)
+		}
+	}
+
+	msg, err := storage.Deliver(store, "target", "Subject: Match\r\n\r\nHi", nil)
+
+	if err != nil {
+		t.Fatalf("Cannot deliver message: %s", err)
+	}
+
+	<-done
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: expected %d, got %d", http.StatusOK, recorder.Code)
+	}
+
+	var details struct {
+		ID string `json:"id"`
+	}
+
+	if err := json.NewDecoder(recorder.Body).Decode(&details); err != nil {
+		t.Fatalf("Cannot decode message details: %s", err)
+	}
+
+	if details.ID != msg.ID {
+		t.Errorf("Unexpected message: expected \"%s\", got \"%s\"", msg.ID, details.ID)
+	}
+}
+
+// slowStorage represents storage with the smallest event buffer possible, which signals the first subscription.
+type slowStorage struct {
+	*storage.MemoryStorage
+
+	subscribed chan struct{}
+}
+
+func (store *slowStorage) Subscribe(bufferSize int) *storage.Subscription {
+	defer close(store.subscribed)
+
+	return store.MemoryStorage.Subscribe(1)
+}
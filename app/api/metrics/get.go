@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"zinktray/app/api/context"
+	metrics2 "zinktray/app/metrics"
+)
+
+// GetMetricsHandler creates handler for metrics retrieval API.
+//
+// Responds with SMTP and HTTP API counters, along with storage totals, in Prometheus text format.
+func GetMetricsHandler(context *context.RequestHandlerContext) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		var buffer bytes.Buffer
+
+		context.Metrics.Write(&buffer, context.Store.GetStats())
+
+		response.Header().Add("Content-Type", metrics2.ContentType)
+		response.Write(buffer.Bytes())
+	}
+}
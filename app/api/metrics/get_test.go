@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"zinktray/app/api/context"
+	metrics2 "zinktray/app/metrics"
+	"zinktray/app/storage"
+)
+
+func TestGetMetrics(t *testing.T) {
+	var store = storage.NewMemoryStorage()
+	var collector = metrics2.NewMetrics()
+	var recorder = httptest.NewRecorder()
+
+	if _, err := storage.Deliver(store, "bob", "Subject: Welcome\r\n\r\nHello\r\n", nil); err != nil {
+		t.Fatalf("Cannot deliver message: %s", err)
+	}
+
+	collector.SmtpAuth(true)
+	collector.MessageAccepted(30)
+
+	var handler = collector.Instrument("/api/mailboxes/list", http.NotFoundHandler())
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/mailboxes/list", nil))
+
+	GetMetricsHandler(&context.RequestHandlerContext{Store: store, Metrics: collector})(
+		recorder,
+		httptest.NewRequest(http.MethodGet, "/metrics", nil),
+	)
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != metrics2.ContentType {
+		t.Errorf("Unexpected content type: %s", contentType)
+	}
+
+	for _, expected := range []string{
+		"zinktray_smtp_messages_accepted_total 1\n",
+		"zinktray_smtp_auth_total{result=\"success\"} 1\n",
+		"zinktray_api_requests_total{route=\"/api/mailboxes/list\",status=\"404\"} 1\n",
+		"zinktray_mailboxes 1\n",
+		"zinktray_messages 1\n",
+		"zinktray_stored_bytes 27\n",
+	} {
+		if !strings.Contains(recorder.Body.String(), expected) {
+			t.Errorf("Metrics are expected to contain %q, got %q", expected, recorder.Body.String())
+		}
+	}
+}
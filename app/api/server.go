@@ -4,18 +4,39 @@ import (
 	"context"
 	"errors"
 	"log"
+	"net"
 	"net/http"
 	"sync"
 	"time"
+	"zinktray/app/api/certificate"
 	context2 "zinktray/app/api/context"
+	"zinktray/app/api/event"
 	"zinktray/app/api/mailbox"
 	"zinktray/app/api/message"
+	metrics2 "zinktray/app/api/metrics"
+	"zinktray/app/api/ui"
+	certificate2 "zinktray/app/certificate"
+	"zinktray/app/config"
+	"zinktray/app/metrics"
+	"zinktray/app/relay"
 	"zinktray/app/storage"
 )
 
 // Server structure represents HTTP API server.
 type Server struct {
-	storage *storage.Storage
+	// certificate contains TLS certificate served by mail servers. nil when TLS is not enabled.
+	certificate *certificate2.Bundle
+
+	// config contains HTTP API server configuration.
+	config config.ApiConfig
+
+	// metrics collects counters of mail flowing through the server, HTTP API requests included.
+	metrics *metrics.Metrics
+
+	// relay provides upstream SMTP relay stored messages are released to.
+	relay *relay.Relay
+
+	storage storage.Storage
 }
 
 // Start wires-up HTTP API server.
@@ -24,10 +45,14 @@ type Server struct {
 func (srv *Server) Start(ctx context.Context, waitGroup *sync.WaitGroup) {
 	defer waitGroup.Done()
 
-	srv.addHandlers()
-
 	server := &http.Server{
-		Addr: "127.0.0.1:8080",
+		Addr:    srv.config.Addr,
+		Handler: srv.Handler(),
+
+		// Long-living requests (e.g. event streams) are cancelled as soon as the server is going to terminate.
+		BaseContext: func(_ net.Listener) context.Context {
+			return ctx
+		},
 	}
 
 	go func() {
@@ -49,23 +74,61 @@ func (srv *Server) Start(ctx context.Context, waitGroup *sync.WaitGroup) {
 	}
 }
 
-// addHandlers registers HTTP API endpoints and their handlers.
-func (srv *Server) addHandlers() {
+// Handler creates handler serving HTTP API endpoints, as well as web inbox UI.
+//
+// Every call creates a separate handler, so that it may be mounted elsewhere, e.g. onto a test server.
+func (srv *Server) Handler() http.Handler {
 	requestHandlerContext := &context2.RequestHandlerContext{
-		Store: srv.storage,
+		Store:       srv.storage,
+		Certificate: srv.certificate,
+		Relay:       srv.relay,
+		Metrics:     srv.metrics,
 	}
 
-	http.Handle("/api/mailboxes/delete", mailbox.DeleteMailboxHandler(requestHandlerContext))
-	http.Handle("/api/mailboxes/list", mailbox.GetMailboxListHandler(requestHandlerContext))
+	mux := http.NewServeMux()
+
+	// Every request is counted by route it matches.
+	handle := func(route string, handler http.Handler) {
+		mux.Handle(route, srv.metrics.Instrument(route, handler))
+	}
+
+	handle("/api/certificates/ca", certificate.GetCaCertificateHandler(requestHandlerContext))
+
+	handle("/api/events/stream", event.StreamEventsHandler(requestHandlerContext))
+
+	handle("/api/mailboxes/delete", mailbox.DeleteMailboxHandler(requestHandlerContext))
+	handle("/api/mailboxes/list", mailbox.GetMailboxListHandler(requestHandlerContext))
+
+	handle("/api/messages", message.AddMessageHandler(requestHandlerContext))
+	handle("/api/messages/delete", message.DeleteMessageHandler(requestHandlerContext))
+	handle("/api/messages/list", message.GetMessageListHandler(requestHandlerContext))
+	handle("/api/messages/search", message.SearchMessagesHandler(requestHandlerContext))
+	handle("/api/messages/details", message.GetMessageDetailsHandler(requestHandlerContext))
+	handle("/api/messages/wait", message.WaitMessageHandler(requestHandlerContext))
+	handle("/api/messages/attachment", message.GetAttachmentHandler(requestHandlerContext))
+	handle("/api/messages/html", message.GetMessageHtmlHandler(requestHandlerContext))
+	handle("/api/messages/release", message.ReleaseMessageHandler(requestHandlerContext))
+
+	handle("/metrics", metrics2.GetMetricsHandler(requestHandlerContext))
+
+	handle("/", ui.Handler())
 
-	http.Handle("/api/messages/delete", message.DeleteMessageHandler(requestHandlerContext))
-	http.Handle("/api/messages/list", message.GetMessageListHandler(requestHandlerContext))
-	http.Handle("/api/messages/details", message.GetMessageDetailsHandler(requestHandlerContext))
+	return mux
 }
 
 // NewServer creates new HTTP API server structure.
-func NewServer(storage *storage.Storage) *Server {
+func NewServer(
+	storage storage.Storage,
+	config config.ApiConfig,
+	certificate *certificate2.Bundle,
+	relay *relay.Relay,
+	metrics *metrics.Metrics,
+) *Server {
 	return &Server{
-		storage: storage,
+		certificate: certificate,
+		config:      config,
+		metrics:     metrics,
+		relay:       relay,
+		storage:     storage,
 	}
 }
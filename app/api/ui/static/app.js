@@ -0,0 +1,334 @@
+"use strict";
+
+const state = {
+    mailboxId: null,
+    messageId: null,
+    search: "",
+    tab: "html",
+};
+
+const element = (id) => document.getElementById(id);
+
+async function request(path, params = {}, method = "GET") {
+    const query = new URLSearchParams(params).toString();
+    const response = await fetch(query ? `${path}?${query}` : path, {method});
+
+    if (!response.ok) {
+        throw new Error(`${method} ${path} failed: HTTP ${response.status}`);
+    }
+
+    const type = response.headers.get("Content-Type") || "";
+
+    return type.includes("application/json") ? response.json() : null;
+}
+
+function create(tag, className, text) {
+    const node = document.createElement(tag);
+
+    if (className) {
+        node.className = className;
+    }
+
+    if (text !== undefined) {
+        node.textContent = text;
+    }
+
+    return node;
+}
+
+function formatTime(unix) {
+    return new Date(unix * 1000).toLocaleString();
+}
+
+function formatSize(size) {
+    if (size < 1024) {
+        return `${size} B`;
+    }
+
+    if (size < 1024 * 1024) {
+        return `${(size / 1024).toFixed(1)} KiB`;
+    }
+
+    return `${(size / 1024 / 1024).toFixed(1)} MiB`;
+}
+
+// Splits raw message header section into a list of unfolded [name, value] pairs.
+function parseHeaders(raw) {
+    const end = raw.search(/\r?\n\r?\n/);
+    const section = end === -1 ? raw : raw.slice(0, end);
+    const headers = [];
+
+    for (const line of section.split(/\r?\n/)) {
+        if (/^[ \t]/.test(line) && headers.length > 0) {
+            headers[headers.length - 1][1] += " " + line.trim();
+        } else {
+            const colon = line.indexOf(":");
+
+            if (colon > 0) {
+                headers.push([line.slice(0, colon), line.slice(colon + 1).trim()]);
+            }
+        }
+    }
+
+    return headers;
+}
+
+function fillTable(tbody, rows) {
+    tbody.replaceChildren(...rows.map(([name, value]) => {
+        const row = create("tr");
+
+        row.append(create("th", null, name), create("td", null, value));
+
+        return row;
+    }));
+}
+
+async function loadMailboxes() {
+    const {mailboxes} = await request("/api/mailboxes/list");
+    const list = element("mailbox-list");
+
+    list.replaceChildren(...mailboxes.map((mailbox) => {
+        const item = create("li", mailbox.id === state.mailboxId ? "selected" : "", mailbox.id);
+
+        item.addEventListener("click", () => selectMailbox(mailbox.id));
+
+        return item;
+    }));
+
+    element("mailbox-empty").hidden = mailboxes.length > 0;
+
+    if (state.mailboxId !== null && !mailboxes.some((mailbox) => mailbox.id === state.mailboxId)) {
+        selectMailbox(null);
+    }
+}
+
+async function loadMessages() {
+    const list = element("message-list");
+    const searching = state.search !== "";
+
+    const title = (searching ? "Search in " : "")
+        + (state.mailboxId === null ? (searching ? "all mailboxes" : "Messages") : state.mailboxId);
+
+    element("message-list-title").textContent = title;
+    element("delete-mailbox").hidden = state.mailboxId === null;
+
+    if (state.mailboxId === null && !searching) {
+        list.replaceChildren();
+        element("message-empty").textContent = "Select a mailbox.";
+        element("message-empty").hidden = false;
+
+        return;
+    }
+
+    const {messages, total} = searching
+        ? await request("/api/messages/search", {query: state.search, mailbox_id: state.mailboxId ?? ""})
+        : await request("/api/messages/list", {mailbox_id: state.mailboxId});
+
+    list.replaceChildren(...messages.map((message) => {
+        const item = create("li", message.id === state.messageId ? "selected" : "");
+
+        item.append(
+            create("div", "subject", message.subject || "(no subject)"),
+            create("div", "meta", message.from.join(", ") || "(no sender)"),
+            create("div", "meta", `${formatTime(message.receivedAt)}, ${formatSize(message.size)}`
+                + (message.hasAttachments ? ", with attachments" : "")),
+            create("div", "meta", message.error === null ? message.snippet : `Cannot parse: ${message.error}`),
+        );
+        item.addEventListener("click", () => selectMessage(message.id));
+
+        return item;
+    }));
+
+    element("message-list-title").textContent = `${title} (${total})`;
+    element("message-empty").textContent = searching ? "Nothing found." : "Mailbox is empty.";
+    element("message-empty").hidden = messages.length > 0;
+}
+
+async function loadMessage() {
+    const preview = element("preview");
+
+    element("preview-empty").hidden = state.messageId !== null;
+    preview.hidden = state.messageId === null;
+
+    if (state.messageId === null) {
+        element("preview-html").removeAttribute("src");
+
+        return;
+    }
+
+    const message = await request("/api/messages/details", {message_id: state.messageId});
+    const hasHtml = message.content.html !== null;
+
+    element("preview-subject").textContent = message.subject || "(no subject)";
+    element("preview-from").textContent = message.from.join(", ");
+    element("preview-to").textContent = message.to.join(", ");
+    element("preview-received").textContent = formatTime(message.receivedAt);
+
+    element("preview-html").hidden = !hasHtml;
+    element("preview-html-empty").hidden = hasHtml;
+
+    if (hasHtml) {
+        element("preview-html").src = "/api/messages/html?" + new URLSearchParams({message_id: message.id});
+    } else {
+        element("preview-html").removeAttribute("src");
+    }
+
+    element("preview-text").textContent = message.content.text ?? "";
+    element("preview-raw").textContent = message.content.raw;
+
+    fillTable(element("preview-headers"), parseHeaders(message.content.raw));
+    fillTable(element("preview-envelope"), envelopeRows(message.envelope));
+
+    const attachments = element("preview-attachments");
+
+    attachments.replaceChildren(...message.attachments.map((attachment) => {
+        const item = create("li");
+        const link = create("a", null, attachment.filename || `attachment-${attachment.index}`);
+
+        link.href = "/api/messages/attachment?" + new URLSearchParams({
+            message_id: message.id,
+            index: attachment.index,
+        });
+        item.append(link, ` ${attachment.contentType}, ${formatSize(attachment.size)}`);
+
+        return item;
+    }));
+
+    element("preview-attachments-empty").hidden = message.attachments.length > 0;
+
+    if (state.tab === "html" && !hasHtml) {
+        selectTab("text");
+    }
+}
+
+function envelopeRows(envelope) {
+    if (envelope === null) {
+        return [["", "Envelope is not available."]];
+    }
+
+    return [
+        ["Return-Path", envelope.returnPath],
+        ["Recipients", envelope.recipients.map((recipient) => recipient.address).join(", ")],
+        ["HELO", envelope.helo],
+        ["Client IP", envelope.clientIp],
+        ["TLS", envelope.tls ? "yes" : "no"],
+        ["Authenticated as", envelope.authUsername],
+        ["Size", envelope.size ? formatSize(envelope.size) : ""],
+        ["Body", envelope.body],
+        ["SMTPUTF8", envelope.smtpUtf8 ? "yes" : "no"],
+        ["REQUIRETLS", envelope.requireTls ? "yes" : "no"],
+    ].filter(([, value]) => value !== "");
+}
+
+function selectMailbox(mailboxId) {
+    state.mailboxId = mailboxId;
+    state.messageId = null;
+
+    for (const item of element("mailbox-list").children) {
+        item.classList.toggle("selected", item.textContent === mailboxId);
+    }
+
+    loadMessages().catch(report);
+    loadMessage().catch(report);
+}
+
+function selectMessage(messageId) {
+    state.messageId = messageId;
+
+    loadMessages().catch(report);
+    loadMessage().catch(report);
+}
+
+function selectTab(tab) {
+    state.tab = tab;
+
+    for (const button of document.querySelectorAll("[data-tab]")) {
+        button.classList.toggle("active", button.dataset.tab === tab);
+        button.setAttribute("aria-selected", button.dataset.tab === tab);
+    }
+
+    for (const panel of document.querySelectorAll("[data-panel]")) {
+        panel.hidden = panel.dataset.panel !== tab;
+    }
+}
+
+function report(error) {
+    console.error(error);
+    element("status").textContent = error.message;
+}
+
+function subscribe() {
+    const stream = new EventSource("/api/events/stream");
+
+    stream.addEventListener("open", () => {
+        element("status").textContent = "Live";
+    });
+    stream.addEventListener("error", () => {
+        element("status").textContent = "Reconnecting…";
+    });
+
+    // Events have been dropped, so that anything might have changed.
+    stream.addEventListener("resync", () => {
+        loadMailboxes().catch(report);
+
+        if (state.mailboxId !== null || state.search !== "") {
+            loadMessages().catch(report);
+        }
+    });
+
+    for (const type of ["mailbox.added", "mailbox.deleted"]) {
+        stream.addEventListener(type, () => loadMailboxes().catch(report));
+    }
+
+    for (const type of ["message.added", "message.deleted"]) {
+        stream.addEventListener(type, (event) => {
+            const info = JSON.parse(event.data);
+
+            if (state.mailboxId !== null && info.mailboxId !== state.mailboxId) {
+                return;
+            }
+
+            if (type === "message.deleted" && info.messageId === state.messageId) {
+                selectMessage(null);
+            } else if (state.mailboxId !== null || state.search !== "") {
+                loadMessages().catch(report);
+            }
+        });
+    }
+}
+
+element("delete-mailbox").addEventListener("click", () => {
+    if (state.mailboxId !== null && confirm(`Delete mailbox "${state.mailboxId}" with all its messages?`)) {
+        request("/api/mailboxes/delete", {mailbox_id: state.mailboxId}, "POST").catch(report);
+    }
+});
+
+element("delete-message").addEventListener("click", () => {
+    if (state.messageId !== null) {
+        request("/api/messages/delete", {message_id: state.messageId}, "POST").catch(report);
+    }
+});
+
+element("search-form").addEventListener("submit", (event) => {
+    event.preventDefault();
+
+    state.search = element("search").value.trim();
+    state.messageId = null;
+
+    loadMessages().catch(report);
+    loadMessage().catch(report);
+});
+
+element("search").addEventListener("search", () => {
+    if (element("search").value === "" && state.search !== "") {
+        element("search-form").requestSubmit();
+    }
+});
+
+for (const button of document.querySelectorAll("[data-tab]")) {
+    button.addEventListener("click", () => selectTab(button.dataset.tab));
+}
+
+selectTab(state.tab);
+loadMailboxes().catch(report);
+subscribe();
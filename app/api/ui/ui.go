@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// static contains web inbox assets.
+//
+//go:embed static
+var static embed.FS
+
+// Handler creates handler serving web inbox UI.
+//
+// The UI is a single page application built on top of HTTP API.
+func Handler() http.Handler {
+	assets, err := fs.Sub(static, "static")
+
+	if err != nil {
+		// Embedded directory is always present.
+		panic(err)
+	}
+
+	fileServer := http.FileServer(http.FS(assets))
+
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		response.Header().Add("Content-Security-Policy", "default-src 'self'; frame-src 'self'; img-src 'self' data:")
+		response.Header().Add("X-Content-Type-Options", "nosniff")
+
+		fileServer.ServeHTTP(response, request)
+	})
+}
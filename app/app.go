@@ -7,7 +7,11 @@ import (
 	"sync"
 	"syscall"
 	"zinktray/app/api"
+	"zinktray/app/imap"
+	"zinktray/app/pop3"
+	"zinktray/app/retention"
 	"zinktray/app/smtp"
+	"zinktray/app/webhook"
 )
 
 // Application represents the application itself.
@@ -19,6 +23,18 @@ type Application struct {
 
 	// Configured SMTP server
 	smtpServer *smtp.SmtpServer
+
+	// Configured POP3 server
+	pop3Server *pop3.Pop3Server
+
+	// Configured IMAP server
+	imapServer *imap.ImapServer
+
+	// Configured retention janitor
+	janitor *retention.Janitor
+
+	// Configured webhook notifier
+	notifier *webhook.Notifier
 }
 
 // Start starts all application subsystems and awaits their termination.
@@ -35,8 +51,24 @@ func (app *Application) Start(ctx context.Context) {
 
 	waitGroup.Add(1)
 
+	go app.pop3Server.Start(appContext, waitGroup)
+
+	waitGroup.Add(1)
+
+	go app.imapServer.Start(appContext, waitGroup)
+
+	waitGroup.Add(1)
+
 	go app.apiServer.Start(appContext, waitGroup)
 
+	waitGroup.Add(1)
+
+	go app.janitor.Start(appContext, waitGroup)
+
+	waitGroup.Add(1)
+
+	go app.notifier.Start(appContext, waitGroup)
+
 	waitGroup.Wait()
 }
 
@@ -54,9 +86,20 @@ func (app *Application) watchTerminationSignal(cancel context.CancelFunc) {
 }
 
 // NewApp creates new application structure.
-func NewApp(smtpServer *smtp.SmtpServer, apiServer *api.Server) *Application {
+func NewApp(
+	smtpServer *smtp.SmtpServer,
+	pop3Server *pop3.Pop3Server,
+	imapServer *imap.ImapServer,
+	apiServer *api.Server,
+	janitor *retention.Janitor,
+	notifier *webhook.Notifier,
+) *Application {
 	return &Application{
 		apiServer:  apiServer,
 		smtpServer: smtpServer,
+		pop3Server: pop3Server,
+		imapServer: imapServer,
+		janitor:    janitor,
+		notifier:   notifier,
 	}
 }
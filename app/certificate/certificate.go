@@ -0,0 +1,178 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"time"
+	"zinktray/app/config"
+)
+
+// validity contains lifetime of generated certificates.
+const validity = 365 * 24 * time.Hour
+
+// Bundle structure represents TLS certificate served by mail servers.
+type Bundle struct {
+	// Certificate contains server certificate chain along with its private key.
+	Certificate tls.Certificate
+
+	// TrustPEM contains PEM-encoded certificates clients are expected to trust.
+	//
+	// This is either generated CA certificate, or certificate chain loaded from file.
+	TrustPEM []byte
+
+	// Generated tells whether the certificate has been generated at startup.
+	Generated bool
+}
+
+// TLSConfig creates server TLS configuration serving bundled certificate.
+func (bundle *Bundle) TLSConfig() *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{bundle.Certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// New creates certificate bundle according to configuration.
+//
+// Loads certificate and key from files when configured, otherwise generates in-memory CA and server certificate
+// signed by it.
+func New(cfg config.TlsConfig) (*Bundle, error) {
+	if cfg.CertFile != "" {
+		return Load(cfg.CertFile, cfg.KeyFile)
+	}
+
+	return Generate(cfg.Hosts)
+}
+
+// Load reads PEM-encoded certificate chain and private key from files.
+func Load(certFile string, keyFile string) (*Bundle, error) {
+	certPEM, err := os.ReadFile(certFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read certificate: %w", err)
+	}
+
+	keyPEM, err := os.ReadFile(keyFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read private key: %w", err)
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot load certificate: %w", err)
+	}
+
+	return &Bundle{
+		Certificate: cert,
+		TrustPEM:    certPEM,
+	}, nil
+}
+
+// Generate creates self-signed CA certificate and server certificate signed by it.
+//
+// Server certificate is valid for every host name or IP address listed in hosts.
+func Generate(hosts []string) (*Bundle, error) {
+	if len(hosts) == 0 {
+		return nil, errors.New("cannot generate certificate without host names")
+	}
+
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := notBefore.Add(validity)
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate CA key: %w", err)
+	}
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          newSerialNumber(),
+		Subject:               pkix.Name{Organization: []string{"ZinkTray"}, CommonName: "ZinkTray Test CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate CA certificate: %w", err)
+	}
+
+	caCert, err := x509.ParseCertificate(caDER)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse generated CA certificate: %w", err)
+	}
+
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate server key: %w", err)
+	}
+
+	serverTemplate := &x509.Certificate{
+		SerialNumber: newSerialNumber(),
+		Subject:      pkix.Name{Organization: []string{"ZinkTray"}, CommonName: hosts[0]},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			serverTemplate.IPAddresses = append(serverTemplate.IPAddresses, ip)
+		} else {
+			serverTemplate.DNSNames = append(serverTemplate.DNSNames, host)
+		}
+	}
+
+	serverDER, err := x509.CreateCertificate(rand.Reader, serverTemplate, caCert, &serverKey.PublicKey, caKey)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate server certificate: %w", err)
+	}
+
+	var trustPEM bytes.Buffer
+
+	if err := pem.Encode(&trustPEM, &pem.Block{Type: "CERTIFICATE", Bytes: caDER}); err != nil {
+		return nil, fmt.Errorf("cannot encode CA certificate: %w", err)
+	}
+
+	return &Bundle{
+		Certificate: tls.Certificate{
+			Certificate: [][]byte{serverDER, caDER},
+			PrivateKey:  serverKey,
+		},
+		TrustPEM:  trustPEM.Bytes(),
+		Generated: true,
+	}, nil
+}
+
+// newSerialNumber generates random certificate serial number.
+func newSerialNumber() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+
+	if err != nil {
+		panic(fmt.Sprintf("Cannot read random bytes: %s", err))
+	}
+
+	return serial
+}
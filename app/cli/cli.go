@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"zinktray/client"
+)
+
+// Command describes command inspecting a running server through HTTP API.
+type Command struct {
+	// Name contains name the command is invoked by.
+	Name string
+
+	// Args contains synopsis of positional arguments.
+	Args string
+
+	// Description contains short description of the command.
+	Description string
+
+	// nargs contains number of positional arguments expected.
+	nargs int
+
+	// define defines command flags and returns function executing the command.
+	define func(flags *flag.FlagSet) runner
+}
+
+// runner executes command with positional arguments, writing results to output.
+type runner func(ctx context.Context, api *client.Client, args []string, output io.Writer) error
+
+// Commands contains every command available.
+var Commands = []*Command{
+	mailboxesCommand,
+	messagesCommand,
+	showCommand,
+	tailCommand,
+	purgeCommand,
+}
+
+// Find looks for command by name. Returns nil when no command is found.
+func Find(name string) *Command {
+	for _, command := range Commands {
+		if command.Name == name {
+			return command
+		}
+	}
+
+	return nil
+}
+
+// Run executes command with command-line arguments, writing results to output and usage to errorOutput.
+//
+// Flags may follow positional arguments. HTTP API is reached at baseURL unless "-url" flag is given. Returns
+// flag.ErrHelp when help is requested.
+func (command *Command) Run(
+	ctx context.Context,
+	baseURL string,
+	args []string,
+	output io.Writer,
+	errorOutput io.Writer,
+) error {
+	flags := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+	flags.SetOutput(errorOutput)
+
+	apiURL := flags.String("url", baseURL, "base `URL` of HTTP API")
+	run := command.define(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(errorOutput, "Usage: zinktray %s [flags] %s\n\n%s\n\n", command.Name, command.Args, command.Description)
+		flags.PrintDefaults()
+	}
+
+	positional, err := parseArgs(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != command.nargs {
+		flags.Usage()
+
+		return fmt.Errorf("expected %d arguments, got %d", command.nargs, len(positional))
+	}
+
+	return run(ctx, client.NewClient(*apiURL, nil), positional, output)
+}
+
+// parseArgs parses flags interleaved with positional arguments and returns the latter.
+//
+// Arguments following "--" are positional.
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+
+		rest := flags.Args()
+
+		if len(rest) == 0 {
+			return positional, nil
+		}
+
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// BaseURL returns base URL of HTTP API served on provided listen address.
+//
+// Unspecified host stands for localhost.
+func BaseURL(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+
+	if err != nil {
+		return "http://" + addr
+	}
+
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+
+	return "http://" + net.JoinHostPort(host, port)
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"zinktray/client"
+	"zinktray/ztest"
+)
+
+func TestCommands(t *testing.T) {
+	var srv = ztest.Start(t)
+	var api = client.NewClient(srv.APIURL, nil)
+	var ctx = context.Background()
+
+	id, err := api.AddMessage(ctx, "bob", "From: alice@example.com\r\nSubject: Hello\r\n\r\nHi there\r\n")
+
+	if err != nil {
+		t.Fatalf("Cannot add message: %s", err)
+	}
+
+	if _, err := api.AddMessage(ctx, "carol", "Subject: Other\r\n\r\nText\r\n"); err != nil {
+		t.Fatalf("Cannot add message: %s", err)
+	}
+
+	var expected = []struct {
+		args     []string
+		contains []string
+	}{
+		{[]string{"mailboxes"}, []string{"bob\ncarol\n"}},
+		{[]string{"mailboxes", "-contains", "car"}, []string{"carol\n"}},
+		{[]string{"messages", "bob"}, []string{"ID", "SUBJECT", id, "<alice@example.com>", "Hello"}},
+		{[]string{"show", id}, []string{"Subject:  Hello\n", "\nHi there\r\n"}},
+		{[]string{"show", id, "--raw"}, []string{"From: alice@example.com\r\nSubject: Hello\r\n\r\nHi there\r\n"}},
+		{[]string{"show", "-text", id}, []string{"Hi there\r\n"}},
+		{[]string{"purge", "-mailbox", "carol"}, []string{"Deleted 1 mailboxes\n"}},
+		{[]string{"mailboxes"}, []string{"bob\n"}},
+	}
+
+	for _, test := range expected {
+		var output = run(t, srv.APIURL, test.args...)
+
+		for _, substr := range test.contains {
+			if !strings.Contains(output, substr) {
+				t.Errorf("Output of %q is expected to contain %q, got %q", test.args, substr, output)
+			}
+		}
+	}
+
+	if output := run(t, srv.APIURL, "mailboxes", "-contains", "car"); output != "" {
+		t.Errorf("Purged mailbox is expected to be deleted, got %q", output)
+	}
+
+	var failing = [][]string{
+		{"show", id, "-html"},
+		{"show", id, "-raw", "-text"},
+		{"show", "unknown"},
+		{"messages"},
+	}
+
+	for _, args := range failing {
+		if err := Find(args[0]).Run(ctx, srv.APIURL, args[1:], io.Discard, io.Discard); err == nil {
+			t.Errorf("Command %q is expected to fail", args)
+		}
+	}
+
+	if err := Find("show").Run(ctx, srv.APIURL, []string{"-help"}, io.Discard, io.Discard); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Unexpected error: expected \"%s\", got \"%v\"", flag.ErrHelp, err)
+	}
+}
+
+func TestTail(t *testing.T) {
+	var srv = ztest.Start(t)
+	var api = client.NewClient(srv.APIURL, nil)
+	var ctx, cancel = context.WithCancel(context.Background())
+	var output = &lineWriter{lines: make(chan string, 100)}
+	var done = make(chan error, 1)
+
+	go func() {
+		done <- Find("tail").Run(ctx, "http://unused", []string{"-url", srv.APIURL, "-mailbox", "bob"}, output, io.Discard)
+	}()
+
+	var add = func(mailboxID string, subject string) {
+		if _, err := api.AddMessage(context.Background(), mailboxID, "Subject: "+subject+"\r\n\r\nHi\r\n"); err != nil {
+			t.Fatalf("Cannot add message: %s", err)
+		}
+	}
+
+	// Messages stored before the stream is opened are not printed, so that probes are stored until one is printed.
+	for opened := false; !opened; {
+		add("bob", "Probe")
+
+		select {
+		case <-output.lines:
+			opened = true
+		case err := <-done:
+			t.Fatalf("Tail has terminated unexpectedly: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	add("carol", "For carol")
+	add("bob", "For bob")
+
+	for line := range output.lines {
+		if strings.HasSuffix(line, "Probe\n") {
+			continue
+		}
+
+		if !strings.Contains(line, "  bob  ") || !strings.HasSuffix(line, "  For bob\n") {
+			t.Errorf("Unexpected line: %q", line)
+		}
+
+		break
+	}
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Errorf("Interrupted tail is expected to succeed, got %s", err)
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	var expected = map[string]string{
+		"127.0.0.1:8080": "http://127.0.0.1:8080",
+		":8080":          "http://localhost:8080",
+		"0.0.0.0:8080":   "http://localhost:8080",
+		"[::]:8080":      "http://localhost:8080",
+		"[::1]:8080":     "http://[::1]:8080",
+	}
+
+	for addr, url := range expected {
+		if actual := BaseURL(addr); actual != url {
+			t.Errorf("Base URL of %q does not match: got %q, expected %q", addr, actual, url)
+		}
+	}
+}
+
+// run executes command and returns its output.
+func run(t *testing.T, baseURL string, args ...string) string {
+	var output bytes.Buffer
+
+	if err := Find(args[0]).Run(context.Background(), baseURL, args[1:], &output, io.Discard); err != nil {
+		t.Fatalf("Command %q failed: %s", args, err)
+	}
+
+	return output.String()
+}
+
+// lineWriter passes every line written to it over a channel.
+type lineWriter struct {
+	lines chan string
+}
+
+func (writer *lineWriter) Write(data []byte) (int, error) {
+	writer.lines <- string(data)
+
+	return len(data), nil
+}
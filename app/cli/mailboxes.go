@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"zinktray/client"
+)
+
+// mailboxesCommand lists registered mailboxes, one ID per line.
+var mailboxesCommand = &Command{
+	Name:        "mailboxes",
+	Description: "Lists registered mailboxes in order of registration.",
+	define: func(flags *flag.FlagSet) runner {
+		contains := flags.String("contains", "", "list only mailboxes with IDs containing `text`")
+
+		return func(ctx context.Context, api *client.Client, _ []string, output io.Writer) error {
+			list, err := api.ListMailboxes(ctx, *contains, client.ListOptions{})
+
+			if err != nil {
+				return err
+			}
+
+			for _, mbx := range list.Mailboxes {
+				fmt.Fprintln(output, mbx.ID)
+			}
+
+			return nil
+		}
+	},
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"zinktray/client"
+)
+
+// timeFormat contains layout times are printed with.
+const timeFormat = "2006-01-02 15:04:05"
+
+// messagesCommand lists messages of a mailbox, newest first.
+var messagesCommand = &Command{
+	Name:        "messages",
+	Args:        "<mailbox>",
+	Description: "Lists messages stored in mailbox, newest first.",
+	nargs:       1,
+	define: func(flags *flag.FlagSet) runner {
+		limit := flags.Int("limit", 0, "list at most `number` of messages, 0 for no limit")
+
+		return func(ctx context.Context, api *client.Client, args []string, output io.Writer) error {
+			list, err := api.ListMessages(ctx, args[0], client.Filter{}, client.ListOptions{Limit: *limit})
+
+			if err != nil {
+				return err
+			}
+
+			writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+
+			fmt.Fprintln(writer, "ID\tRECEIVED\tFROM\tSUBJECT")
+
+			for _, msg := range list.Messages {
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", msg.ID, formatTime(msg.ReceivedAt), strings.Join(msg.From, ", "), msg.Subject)
+			}
+
+			return writer.Flush()
+		}
+	},
+}
+
+// formatTime formats Unix timestamp in local time zone.
+func formatTime(timestamp int64) string {
+	return time.Unix(timestamp, 0).Format(timeFormat)
+}
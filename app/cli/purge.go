@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"zinktray/client"
+)
+
+// purgeCommand deletes mailboxes along with their messages.
+var purgeCommand = &Command{
+	Name:        "purge",
+	Description: "Deletes every mailbox along with its messages.",
+	define: func(flags *flag.FlagSet) runner {
+		mailboxID := flags.String("mailbox", "", "delete only `mailbox` given")
+
+		return func(ctx context.Context, api *client.Client, _ []string, output io.Writer) error {
+			mailboxIDs := []string{*mailboxID}
+
+			if *mailboxID == "" {
+				list, err := api.ListMailboxes(ctx, "", client.ListOptions{})
+
+				if err != nil {
+					return err
+				}
+
+				mailboxIDs = mailboxIDs[:0]
+
+				for _, mbx := range list.Mailboxes {
+					mailboxIDs = append(mailboxIDs, mbx.ID)
+				}
+			}
+
+			deleted := 0
+
+			for _, id := range mailboxIDs {
+				err := api.DeleteMailbox(ctx, id)
+
+				switch {
+				case err == nil:
+					deleted++
+				case *mailboxID == "" && errors.Is(err, client.ErrNotFound):
+					// Mailbox has been deleted in between.
+				default:
+					return fmt.Errorf("cannot delete mailbox %q: %w", id, err)
+				}
+			}
+
+			_, err := fmt.Fprintf(output, "Deleted %d mailboxes\n", deleted)
+
+			return err
+		}
+	},
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"zinktray/client"
+)
+
+// showCommand prints stored message.
+var showCommand = &Command{
+	Name:        "show",
+	Args:        "<id>",
+	Description: "Prints message headers and plain-text content, or the part of message requested.",
+	nargs:       1,
+	define: func(flags *flag.FlagSet) runner {
+		raw := flags.Bool("raw", false, "print raw message contents")
+		html := flags.Bool("html", false, "print HTML content")
+		text := flags.Bool("text", false, "print plain-text content only")
+
+		return func(ctx context.Context, api *client.Client, args []string, output io.Writer) error {
+			if countSet(*raw, *html, *text) > 1 {
+				return errors.New("-raw, -html and -text flags are mutually exclusive")
+			}
+
+			msg, err := api.GetMessage(ctx, args[0])
+
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case *raw:
+				_, err = io.WriteString(output, msg.Content.Raw)
+			case *html:
+				err = writeContent(output, msg.Content.Html, "HTML")
+			case *text:
+				err = writeContent(output, msg.Content.Text, "plain-text")
+			default:
+				err = writeMessage(output, msg)
+			}
+
+			return err
+		}
+	},
+}
+
+// writeMessage writes message headers, attachment list and plain-text content.
+func writeMessage(output io.Writer, msg *client.MessageDetails) error {
+	fmt.Fprintf(output, "ID:       %s\n", msg.ID)
+	fmt.Fprintf(output, "Received: %s\n", formatTime(msg.ReceivedAt))
+	fmt.Fprintf(output, "From:     %s\n", strings.Join(msg.From, ", "))
+	fmt.Fprintf(output, "To:       %s\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(output, "Subject:  %s\n", msg.Subject)
+
+	for _, attachment := range msg.Attachments {
+		fmt.Fprintf(output, "Attached: %s (%s, %d bytes)\n", attachment.Filename, attachment.ContentType, attachment.Size)
+	}
+
+	fmt.Fprintln(output)
+
+	switch {
+	case msg.Content.Text != nil:
+		return writeContent(output, msg.Content.Text, "plain-text")
+	case msg.Content.Html != nil:
+		_, err := fmt.Fprintln(output, "(HTML content only, use -html flag to print it)")
+
+		return err
+	default:
+		_, err := fmt.Fprintln(output, "(no content)")
+
+		return err
+	}
+}
+
+// writeContent writes message content of provided kind, followed by line break if necessary.
+func writeContent(output io.Writer, content *string, kind string) error {
+	if content == nil {
+		return fmt.Errorf("message has no %s content", kind)
+	}
+
+	if _, err := io.WriteString(output, *content); err != nil {
+		return err
+	}
+
+	if !strings.HasSuffix(*content, "\n") {
+		_, err := fmt.Fprintln(output)
+
+		return err
+	}
+
+	return nil
+}
+
+// countSet returns number of flags set.
+func countSet(values ...bool) int {
+	count := 0
+
+	for _, value := range values {
+		if value {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"zinktray/client"
+)
+
+// tailCommand prints messages as they arrive.
+var tailCommand = &Command{
+	Name:        "tail",
+	Description: "Follows new mail, printing every message as it arrives until interrupted.",
+	define: func(flags *flag.FlagSet) runner {
+		mailboxID := flags.String("mailbox", "", "follow only messages stored into `mailbox`")
+
+		return func(ctx context.Context, api *client.Client, _ []string, output io.Writer) error {
+			stream, err := api.StreamEvents(ctx, *mailboxID)
+
+			if err != nil {
+				return err
+			}
+
+			defer stream.Close()
+
+			for {
+				event, err := stream.Next()
+
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+
+					return err
+				}
+
+				if event.Type != "message.added" {
+					continue
+				}
+
+				msg, err := api.GetMessage(ctx, event.MessageID)
+
+				if errors.Is(err, client.ErrNotFound) {
+					// The message has been deleted already.
+					continue
+				} else if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(
+					output,
+					"%s  %s  %s  %s  %s\n",
+					formatTime(msg.ReceivedAt),
+					event.MailboxID,
+					msg.ID,
+					strings.Join(msg.From, ", "),
+					msg.Subject,
+				)
+			}
+		}
+	},
+}
@@ -0,0 +1,581 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// Config contains complete application configuration.
+//
+// Use Default to acquire configuration with every option set to its default value,
+// or Load to acquire configuration assembled out of configuration file, environment variables and command-line flags.
+type Config struct {
+	// SMTP contains SMTP server configuration.
+	SMTP SmtpConfig `json:"smtp" yaml:"smtp" toml:"smtp"`
+
+	// POP3 contains POP3 server configuration.
+	POP3 Pop3Config `json:"pop3" yaml:"pop3" toml:"pop3"`
+
+	// IMAP contains IMAP server configuration.
+	IMAP ImapConfig `json:"imap" yaml:"imap" toml:"imap"`
+
+	// API contains HTTP API server configuration.
+	API ApiConfig `json:"api" yaml:"api" toml:"api"`
+
+	// TLS contains TLS certificate configuration shared by mail servers.
+	TLS TlsConfig `json:"tls" yaml:"tls" toml:"tls"`
+
+	// Storage contains message storage configuration.
+	Storage StorageConfig `json:"storage" yaml:"storage" toml:"storage"`
+
+	// Retention contains message retention configuration.
+	Retention RetentionConfig `json:"retention" yaml:"retention" toml:"retention"`
+
+	// Webhooks contains configuration of webhooks notified upon message arrival.
+	Webhooks WebhookConfig `json:"webhooks" yaml:"webhooks" toml:"webhooks"`
+
+	// Relay contains configuration of upstream SMTP relay stored messages are released to.
+	Relay RelayConfig `json:"relay" yaml:"relay" toml:"relay"`
+
+	// Sendmail contains configuration of sendmail-compatible command.
+	Sendmail SendmailConfig `json:"sendmail" yaml:"sendmail" toml:"sendmail"`
+}
+
+// SmtpConfig contains SMTP server configuration.
+type SmtpConfig struct {
+	// Addr contains TCP address to listen on.
+	Addr string `json:"addr" yaml:"addr" toml:"addr"`
+
+	// Domain contains server domain name announced in greeting.
+	Domain string `json:"domain" yaml:"domain" toml:"domain"`
+
+	// ReadTimeout contains maximum duration of reading a single client command.
+	ReadTimeout Duration `json:"read_timeout" yaml:"read_timeout" toml:"read_timeout"`
+
+	// WriteTimeout contains maximum duration of writing a single server response.
+	WriteTimeout Duration `json:"write_timeout" yaml:"write_timeout" toml:"write_timeout"`
+
+	// MaxMessageBytes contains maximum accepted message size in bytes.
+	MaxMessageBytes int64 `json:"max_message_bytes" yaml:"max_message_bytes" toml:"max_message_bytes"`
+
+	// MaxRecipients contains maximum number of recipients of a single message. Zero means no limit.
+	MaxRecipients int `json:"max_recipients" yaml:"max_recipients" toml:"max_recipients"`
+
+	// Routing contains mailbox routing mode. See Routing* constants for possible values.
+	Routing string `json:"routing" yaml:"routing" toml:"routing"`
+
+	// StartTLS tells whether STARTTLS extension is enabled on plaintext listener.
+	StartTLS bool `json:"starttls" yaml:"starttls" toml:"starttls"`
+
+	// TLSAddr contains TCP address of implicit TLS (SMTPS) listener. Empty string disables the listener.
+	TLSAddr string `json:"tls_addr" yaml:"tls_addr" toml:"tls_addr"`
+}
+
+// Pop3Config contains POP3 server configuration.
+type Pop3Config struct {
+	// Addr contains TCP address to listen on. Empty string disables the server.
+	Addr string `json:"addr" yaml:"addr" toml:"addr"`
+
+	// Timeout contains maximum duration of client inactivity before the session is terminated.
+	Timeout Duration `json:"timeout" yaml:"timeout" toml:"timeout"`
+
+	// StartTLS tells whether STLS command is enabled.
+	StartTLS bool `json:"starttls" yaml:"starttls" toml:"starttls"`
+}
+
+// ImapConfig contains IMAP server configuration.
+type ImapConfig struct {
+	// Addr contains TCP address to listen on. Empty string disables the server.
+	Addr string `json:"addr" yaml:"addr" toml:"addr"`
+
+	// StartTLS tells whether STARTTLS command is enabled.
+	StartTLS bool `json:"starttls" yaml:"starttls" toml:"starttls"`
+}
+
+// ApiConfig contains HTTP API server configuration.
+type ApiConfig struct {
+	// Addr contains TCP address to listen on.
+	Addr string `json:"addr" yaml:"addr" toml:"addr"`
+}
+
+// TlsConfig contains TLS certificate configuration.
+//
+// When no certificate is given, self-signed CA and server certificate signed by it are generated at startup.
+type TlsConfig struct {
+	// CertFile contains path to PEM-encoded server certificate chain.
+	CertFile string `json:"cert_file" yaml:"cert_file" toml:"cert_file"`
+
+	// KeyFile contains path to PEM-encoded server private key.
+	KeyFile string `json:"key_file" yaml:"key_file" toml:"key_file"`
+
+	// Hosts contains host names and IP addresses generated server certificate is valid for.
+	Hosts []string `json:"hosts" yaml:"hosts" toml:"hosts"`
+}
+
+// StorageConfig contains message storage configuration.
+type StorageConfig struct {
+	// Backend contains storage backend name. See Storage* constants for possible values.
+	Backend string `json:"backend" yaml:"backend" toml:"backend"`
+
+	// Path contains path to directory file storage backend keeps messages in.
+	Path string `json:"path" yaml:"path" toml:"path"`
+}
+
+// RetentionConfig contains message retention configuration.
+//
+// Messages exceeding any limit are evicted, oldest first. Zero limits stand for no limit.
+type RetentionConfig struct {
+	// Interval contains period of checking stored messages against limits.
+	Interval Duration `json:"interval" yaml:"interval" toml:"interval"`
+
+	// MaxMessages contains maximum number of messages stored in all mailboxes together.
+	MaxMessages int `json:"max_messages" yaml:"max_messages" toml:"max_messages"`
+
+	// MaxBytes contains maximum total size of messages stored in all mailboxes together, in bytes.
+	MaxBytes int64 `json:"max_bytes" yaml:"max_bytes" toml:"max_bytes"`
+
+	// MaxAge contains maximum age of stored messages.
+	MaxAge Duration `json:"max_age" yaml:"max_age" toml:"max_age"`
+
+	// Mailbox contains limits applied to every mailbox individually.
+	Mailbox RetentionLimits `json:"mailbox" yaml:"mailbox" toml:"mailbox"`
+
+	// Mailboxes maps mailbox ID to limits applied to that mailbox instead of Mailbox limits.
+	Mailboxes map[string]RetentionLimits `json:"mailboxes" yaml:"mailboxes" toml:"mailboxes"`
+}
+
+// RetentionLimits contains limits on messages stored in a mailbox. Zero limits stand for no limit.
+type RetentionLimits struct {
+	// MaxMessages contains maximum number of stored messages.
+	MaxMessages int `json:"max_messages" yaml:"max_messages" toml:"max_messages"`
+
+	// MaxBytes contains maximum total size of stored messages, in bytes.
+	MaxBytes int64 `json:"max_bytes" yaml:"max_bytes" toml:"max_bytes"`
+
+	// MaxAge contains maximum age of stored messages.
+	MaxAge Duration `json:"max_age" yaml:"max_age" toml:"max_age"`
+}
+
+// IsEmpty tells whether no limit is set.
+func (limits RetentionLimits) IsEmpty() bool {
+	return limits.MaxMessages == 0 && limits.MaxBytes == 0 && limits.MaxAge == 0
+}
+
+// Global returns limits applied to all mailboxes together.
+func (cfg *RetentionConfig) Global() RetentionLimits {
+	return RetentionLimits{
+		MaxMessages: cfg.MaxMessages,
+		MaxBytes:    cfg.MaxBytes,
+		MaxAge:      cfg.MaxAge,
+	}
+}
+
+// ForMailbox returns limits applied to mailbox with provided ID.
+func (cfg *RetentionConfig) ForMailbox(mailboxID string) RetentionLimits {
+	if limits, ok := cfg.Mailboxes[mailboxID]; ok {
+		return limits
+	}
+
+	return cfg.Mailbox
+}
+
+// IsEnabled tells whether any limit is set.
+func (cfg *RetentionConfig) IsEnabled() bool {
+	if !cfg.Global().IsEmpty() || !cfg.Mailbox.IsEmpty() {
+		return true
+	}
+
+	for _, limits := range cfg.Mailboxes {
+		if !limits.IsEmpty() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookConfig contains configuration of webhooks notified upon message arrival.
+type WebhookConfig struct {
+	// Endpoints contains webhooks to notify.
+	Endpoints []WebhookEndpoint `json:"endpoints" yaml:"endpoints" toml:"endpoints"`
+
+	// Timeout contains maximum duration of a single notification request.
+	Timeout Duration `json:"timeout" yaml:"timeout" toml:"timeout"`
+
+	// MaxAttempts contains maximum number of attempts to deliver a notification.
+	MaxAttempts int `json:"max_attempts" yaml:"max_attempts" toml:"max_attempts"`
+
+	// RetryInterval contains delay before the first retry. The delay is doubled upon every next retry.
+	RetryInterval Duration `json:"retry_interval" yaml:"retry_interval" toml:"retry_interval"`
+
+	// QueueSize contains maximum number of notifications awaiting delivery to every webhook. Notifications exceeding
+	// the limit are dropped.
+	QueueSize int `json:"queue_size" yaml:"queue_size" toml:"queue_size"`
+}
+
+// WebhookEndpoint contains configuration of individual webhook.
+type WebhookEndpoint struct {
+	// URL contains HTTP or HTTPS URL notifications are posted to.
+	URL string `json:"url" yaml:"url" toml:"url"`
+
+	// Mailboxes contains IDs of mailboxes webhook is notified of. Empty list stands for every mailbox.
+	Mailboxes []string `json:"mailboxes" yaml:"mailboxes" toml:"mailboxes"`
+
+	// Secret contains key notifications are signed with using HMAC-SHA256. Empty string disables signing.
+	Secret string `json:"secret" yaml:"secret" toml:"secret"`
+}
+
+// RelayConfig contains configuration of upstream SMTP relay stored messages are released to.
+type RelayConfig struct {
+	// Addr contains TCP address of upstream SMTP server. Empty string disables message release.
+	Addr string `json:"addr" yaml:"addr" toml:"addr"`
+
+	// TLS contains TLS mode of connection to upstream server. See RelayTLS* constants for possible values.
+	TLS string `json:"tls" yaml:"tls" toml:"tls"`
+
+	// TLSSkipVerify tells whether upstream server certificate is accepted without verification.
+	TLSSkipVerify bool `json:"tls_skip_verify" yaml:"tls_skip_verify" toml:"tls_skip_verify"`
+
+	// Username contains username to authenticate with. Empty string disables authentication.
+	Username string `json:"username" yaml:"username" toml:"username"`
+
+	// Password contains password to authenticate with.
+	Password string `json:"password" yaml:"password" toml:"password"`
+
+	// AllowedDomains contains recipient domains messages may be released to.
+	AllowedDomains []string `json:"allowed_domains" yaml:"allowed_domains" toml:"allowed_domains"`
+}
+
+// SendmailConfig contains configuration of sendmail-compatible command submitting messages to a running server.
+type SendmailConfig struct {
+	// Addr contains TCP address of SMTP server to submit messages to.
+	Addr string `json:"addr" yaml:"addr" toml:"addr"`
+
+	// Username contains username to authenticate with. Empty string disables authentication.
+	Username string `json:"username" yaml:"username" toml:"username"`
+
+	// Password contains password to authenticate with.
+	Password string `json:"password" yaml:"password" toml:"password"`
+}
+
+// Storage backends.
+const (
+	// StorageMemory keeps everything in memory. Stored messages are lost upon restart.
+	StorageMemory = "memory"
+
+	// StorageFile keeps everything in files inside a directory. Stored messages survive restarts.
+	StorageFile = "file"
+)
+
+// Relay TLS modes.
+const (
+	// RelayTLSNone connects to upstream server in plaintext.
+	RelayTLSNone = "none"
+
+	// RelayTLSStartTLS upgrades plaintext connection to upstream server with STARTTLS command.
+	RelayTLSStartTLS = "starttls"
+
+	// RelayTLSImplicit connects to upstream server over TLS (SMTPS).
+	RelayTLSImplicit = "tls"
+)
+
+// Mailbox routing modes.
+//
+// RoutingAuth stores messages into the mailbox named after the username provided during authentication, and requires
+// clients to authenticate. Other modes store one copy of the message per distinct mailbox selected by envelope
+// recipients, and allow anonymous clients.
+const (
+	// RoutingAuth selects mailbox by authentication username.
+	RoutingAuth = "auth"
+
+	// RoutingAddress selects mailbox by complete recipient address (e.g. "user+tag@example.com").
+	RoutingAddress = "address"
+
+	// RoutingDomain selects mailbox by recipient address domain (e.g. "example.com").
+	RoutingDomain = "domain"
+
+	// RoutingTag selects mailbox by recipient address plus-tag (e.g. "tag" out of "user+tag@example.com").
+	//
+	// Addresses without a tag select mailbox by local part (e.g. "user").
+	RoutingTag = "tag"
+)
+
+// Duration represents time duration written in human-readable form (e.g. "30s" or "1m30s").
+type Duration time.Duration
+
+// MarshalText encodes duration into its human-readable form.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText decodes duration from its human-readable form.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+
+	return nil
+}
+
+// Validate tests whether server configuration is usable.
+//
+// Options of features which are disabled, and options of other commands, are not validated. Returns an error
+// describing every invalid option found.
+func (cfg *Config) Validate() error {
+	var errs []error
+
+	if err := validateAddr(cfg.SMTP.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("smtp.addr: %w", err))
+	}
+
+	if cfg.SMTP.Domain == "" {
+		errs = append(errs, errors.New("smtp.domain: must not be empty"))
+	}
+
+	if cfg.SMTP.ReadTimeout <= 0 {
+		errs = append(errs, errors.New("smtp.read_timeout: must be positive"))
+	}
+
+	if cfg.SMTP.WriteTimeout <= 0 {
+		errs = append(errs, errors.New("smtp.write_timeout: must be positive"))
+	}
+
+	if cfg.SMTP.MaxMessageBytes <= 0 {
+		errs = append(errs, errors.New("smtp.max_message_bytes: must be positive"))
+	}
+
+	if cfg.SMTP.MaxRecipients < 0 {
+		errs = append(errs, errors.New("smtp.max_recipients: must not be negative"))
+	}
+
+	switch cfg.SMTP.Routing {
+	case RoutingAuth, RoutingAddress, RoutingDomain, RoutingTag:
+	default:
+		errs = append(errs, fmt.Errorf(
+			"smtp.routing: must be one of %q, %q, %q or %q",
+			RoutingAuth,
+			RoutingAddress,
+			RoutingDomain,
+			RoutingTag,
+		))
+	}
+
+	if cfg.SMTP.TLSAddr != "" {
+		if err := validateAddr(cfg.SMTP.TLSAddr); err != nil {
+			errs = append(errs, fmt.Errorf("smtp.tls_addr: %w", err))
+		}
+	}
+
+	if cfg.POP3.Addr != "" {
+		if err := validateAddr(cfg.POP3.Addr); err != nil {
+			errs = append(errs, fmt.Errorf("pop3.addr: %w", err))
+		}
+	}
+
+	if cfg.POP3.Timeout <= 0 {
+		errs = append(errs, errors.New("pop3.timeout: must be positive"))
+	}
+
+	if cfg.IMAP.Addr != "" {
+		if err := validateAddr(cfg.IMAP.Addr); err != nil {
+			errs = append(errs, fmt.Errorf("imap.addr: %w", err))
+		}
+	}
+
+	if err := validateAddr(cfg.API.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("api.addr: %w", err))
+	}
+
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("tls.cert_file, tls.key_file: must be given together"))
+	}
+
+	if cfg.UsesTLS() && cfg.TLS.CertFile == "" && len(cfg.TLS.Hosts) == 0 {
+		errs = append(errs, errors.New("tls.hosts: must not be empty when certificate is generated"))
+	}
+
+	switch cfg.Storage.Backend {
+	case StorageMemory:
+	case StorageFile:
+		if cfg.Storage.Path == "" {
+			errs = append(errs, errors.New("storage.path: must not be empty for file storage"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("storage.backend: must be one of %q or %q", StorageMemory, StorageFile))
+	}
+
+	if cfg.Retention.Interval <= 0 {
+		errs = append(errs, errors.New("retention.interval: must be positive"))
+	}
+
+	errs = append(errs, validateRetentionLimits("retention", cfg.Retention.Global())...)
+	errs = append(errs, validateRetentionLimits("retention.mailbox", cfg.Retention.Mailbox)...)
+
+	for mailboxID, limits := range cfg.Retention.Mailboxes {
+		errs = append(errs, validateRetentionLimits(fmt.Sprintf("retention.mailboxes.%s", mailboxID), limits)...)
+	}
+
+	for i, endpoint := range cfg.Webhooks.Endpoints {
+		if parsed, err := url.Parse(endpoint.URL); err != nil {
+			errs = append(errs, fmt.Errorf("webhooks.endpoints[%d].url: %w", i, err))
+		} else if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			errs = append(errs, fmt.Errorf("webhooks.endpoints[%d].url: must be absolute HTTP or HTTPS URL", i))
+		}
+	}
+
+	if cfg.Webhooks.Timeout <= 0 {
+		errs = append(errs, errors.New("webhooks.timeout: must be positive"))
+	}
+
+	if cfg.Webhooks.MaxAttempts <= 0 {
+		errs = append(errs, errors.New("webhooks.max_attempts: must be positive"))
+	}
+
+	if cfg.Webhooks.RetryInterval <= 0 {
+		errs = append(errs, errors.New("webhooks.retry_interval: must be positive"))
+	}
+
+	if cfg.Webhooks.QueueSize <= 0 {
+		errs = append(errs, errors.New("webhooks.queue_size: must be positive"))
+	}
+
+	if cfg.Relay.Addr != "" {
+		if err := validateAddr(cfg.Relay.Addr); err != nil {
+			errs = append(errs, fmt.Errorf("relay.addr: %w", err))
+		}
+
+		if len(cfg.Relay.AllowedDomains) == 0 {
+			errs = append(errs, errors.New("relay.allowed_domains: must not be empty when relay is enabled"))
+		}
+	}
+
+	switch cfg.Relay.TLS {
+	case RelayTLSNone, RelayTLSStartTLS, RelayTLSImplicit:
+	default:
+		errs = append(errs, fmt.Errorf(
+			"relay.tls: must be one of %q, %q or %q",
+			RelayTLSNone,
+			RelayTLSStartTLS,
+			RelayTLSImplicit,
+		))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateClient tests whether configuration of commands talking to a running server through HTTP API is usable.
+//
+// Server options other than HTTP API address are not validated, as the commands do not use them.
+func (cfg *Config) ValidateClient() error {
+	if err := validateAddr(cfg.API.Addr); err != nil {
+		return fmt.Errorf("api.addr: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateSendmail tests whether configuration of sendmail-compatible command is usable.
+//
+// Server options are not validated, as the command does not use them.
+func (cfg *Config) ValidateSendmail() error {
+	if err := validateAddr(cfg.Sendmail.Addr); err != nil {
+		return fmt.Errorf("sendmail.addr: %w", err)
+	}
+
+	return nil
+}
+
+// UsesTLS tells whether any server is configured to accept TLS connections.
+func (cfg *Config) UsesTLS() bool {
+	return cfg.SMTP.StartTLS ||
+		cfg.SMTP.TLSAddr != "" ||
+		(cfg.POP3.Addr != "" && cfg.POP3.StartTLS) ||
+		(cfg.IMAP.Addr != "" && cfg.IMAP.StartTLS)
+}
+
+// validateAddr tests whether addr is a valid TCP address to listen on.
+func validateAddr(addr string) error {
+	if addr == "" {
+		return errors.New("must not be empty")
+	}
+
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateRetentionLimits tests whether retention limits are usable. prefix contains option name prefix used in errors.
+func validateRetentionLimits(prefix string, limits RetentionLimits) []error {
+	var errs []error
+
+	if limits.MaxMessages < 0 {
+		errs = append(errs, fmt.Errorf("%s.max_messages: must not be negative", prefix))
+	}
+
+	if limits.MaxBytes < 0 {
+		errs = append(errs, fmt.Errorf("%s.max_bytes: must not be negative", prefix))
+	}
+
+	if limits.MaxAge < 0 {
+		errs = append(errs, fmt.Errorf("%s.max_age: must not be negative", prefix))
+	}
+
+	return errs
+}
+
+// Default creates configuration with every option set to its default value.
+func Default() *Config {
+	return &Config{
+		SMTP: SmtpConfig{
+			Addr:            ":2525",
+			Domain:          "zinktray",
+			ReadTimeout:     Duration(30 * time.Second),
+			WriteTimeout:    Duration(30 * time.Second),
+			MaxMessageBytes: 1024 * 1024,
+			MaxRecipients:   50,
+			Routing:         RoutingAuth,
+		},
+		POP3: Pop3Config{
+			Addr:    ":1110",
+			Timeout: Duration(10 * time.Minute),
+		},
+		IMAP: ImapConfig{
+			Addr: ":1143",
+		},
+		API: ApiConfig{
+			Addr: "127.0.0.1:8080",
+		},
+		TLS: TlsConfig{
+			Hosts: []string{"localhost", "127.0.0.1", "::1"},
+		},
+		Storage: StorageConfig{
+			Backend: StorageMemory,
+			Path:    "data",
+		},
+		Retention: RetentionConfig{
+			Interval: Duration(30 * time.Second),
+		},
+		Webhooks: WebhookConfig{
+			Timeout:       Duration(10 * time.Second),
+			MaxAttempts:   5,
+			RetryInterval: Duration(time.Second),
+			QueueSize:     100,
+		},
+		Relay: RelayConfig{
+			TLS: RelayTLSStartTLS,
+		},
+		Sendmail: SendmailConfig{
+			Addr:     "127.0.0.1:2525",
+			Username: "sendmail",
+		},
+	}
+}
@@ -0,0 +1,275 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var configFiles = map[string]string{
+	"config.yaml": "smtp:\n  addr: \":2626\"\n  read_timeout: 10s\napi:\n  addr: \"127.0.0.1:9090\"\n",
+	"config.toml": "[smtp]\naddr = \":2626\"\nread_timeout = \"10s\"\n\n[api]\naddr = \"127.0.0.1:9090\"\n",
+	"config.json": `{"smtp": {"addr": ":2626", "read_timeout": "10s"}, "api": {"addr": "127.0.0.1:9090"}}`,
+}
+
+func TestLoadDefault(t *testing.T) {
+	cfg, err := Load("zinktray", nil, io.Discard)
+
+	if err != nil {
+		t.Fatalf("Unexpected error upon loading configuration: %s", err)
+	}
+
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Fatalf("Configuration does not match defaults: got %+v, expected %+v", *cfg, *Default())
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	for fileName, contents := range configFiles {
+		t.Run(fileName, func(t *testing.T) {
+			var path = writeFile(t, fileName, contents)
+
+			cfg, err := Load("zinktray", []string{"-config", path}, io.Discard)
+
+			if err != nil {
+				t.Fatalf("Unexpected error upon loading configuration: %s", err)
+			}
+
+			if cfg.SMTP.Addr != ":2626" {
+				t.Errorf("SMTP address does not match: got \"%s\", expected \"%s\"", cfg.SMTP.Addr, ":2626")
+			}
+
+			if cfg.SMTP.ReadTimeout != Duration(10*time.Second) {
+				t.Errorf("SMTP read timeout does not match: got %s, expected %s", &cfg.SMTP.ReadTimeout, "10s")
+			}
+
+			if cfg.SMTP.WriteTimeout != Default().SMTP.WriteTimeout {
+				t.Errorf("Unset option is expected to keep its default value, got %s", &cfg.SMTP.WriteTimeout)
+			}
+
+			if cfg.API.Addr != "127.0.0.1:9090" {
+				t.Errorf("API address does not match: got \"%s\", expected \"%s\"", cfg.API.Addr, "127.0.0.1:9090")
+			}
+		})
+	}
+}
+
+func TestLoadUnknownOption(t *testing.T) {
+	var path = writeFile(t, "config.yaml", "smtp:\n  port: 2626\n")
+
+	if _, err := Load("zinktray", []string{"-config", path}, io.Discard); err == nil {
+		t.Fatal("Loading is expected to fail on unknown option")
+	}
+
+	path = writeFile(t, "config.ini", "")
+
+	if _, err := Load("zinktray", []string{"-config", path}, io.Discard); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("Unexpected error: expected \"%s\", got \"%v\"", ErrUnknownFormat, err)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	var path = writeFile(t, "config.yaml", configFiles["config.yaml"])
+
+	t.Setenv("ZINKTRAY_CONFIG", path)
+	t.Setenv("ZINKTRAY_SMTP_ADDR", ":2727")
+	t.Setenv("ZINKTRAY_SMTP_MAX_RECIPIENTS", "5")
+
+	cfg, err := Load("zinktray", []string{"-smtp-max-recipients", "7"}, io.Discard)
+
+	if err != nil {
+		t.Fatalf("Unexpected error upon loading configuration: %s", err)
+	}
+
+	if cfg.SMTP.Addr != ":2727" {
+		t.Errorf("Environment is expected to override file: got \"%s\", expected \"%s\"", cfg.SMTP.Addr, ":2727")
+	}
+
+	if cfg.SMTP.MaxRecipients != 7 {
+		t.Errorf("Flag is expected to override environment: got %d, expected %d", cfg.SMTP.MaxRecipients, 7)
+	}
+
+	if cfg.API.Addr != "127.0.0.1:9090" {
+		t.Errorf("API address does not match: got \"%s\", expected \"%s\"", cfg.API.Addr, "127.0.0.1:9090")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	t.Setenv("ZINKTRAY_SMTP_READ_TIMEOUT", "soon")
+
+	if _, err := Load("zinktray", nil, io.Discard); err == nil {
+		t.Fatal("Loading is expected to fail on malformed environment variable")
+	}
+
+	os.Unsetenv("ZINKTRAY_SMTP_READ_TIMEOUT")
+
+	if _, err := Load("zinktray", []string{"-smtp-max-message-bytes", "0"}, io.Discard); err == nil {
+		t.Fatal("Loading is expected to fail validation")
+	}
+
+	if _, err := Load("zinktray", []string{"-help"}, io.Discard); !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("Unexpected error: expected \"%s\", got \"%v\"", flag.ErrHelp, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var cfg = Default()
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Default configuration is expected to be valid: %s", err)
+	}
+
+	// Options of disabled features and of other commands are not validated.
+	cfg.TLS.Hosts = nil
+	cfg.Sendmail.Addr = ""
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Configuration is expected to be valid: %s", err)
+	}
+
+	if err := cfg.ValidateSendmail(); err == nil {
+		t.Fatal("Sendmail configuration is expected to be invalid")
+	}
+
+	cfg.SMTP.StartTLS = true
+
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("Configuration generating certificate without hosts is expected to be invalid")
+	}
+
+	cfg = Default()
+	cfg.SMTP.Addr = "2525"
+	cfg.API.Addr = ""
+
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("Configuration is expected to be invalid")
+	}
+}
+
+func TestLoadSendmail(t *testing.T) {
+	t.Setenv("ZINKTRAY_SMTP_ADDR", "2525")
+	t.Setenv("ZINKTRAY_SENDMAIL_ADDR", "127.0.0.1:2626")
+
+	cfg, err := LoadSendmail("sendmail")
+
+	if err != nil {
+		t.Fatalf("Invalid server options are not expected to fail sendmail configuration: %s", err)
+	}
+
+	if cfg.Sendmail.Addr != "127.0.0.1:2626" {
+		t.Errorf("Sendmail address does not match: got \"%s\", expected \"%s\"", cfg.Sendmail.Addr, "127.0.0.1:2626")
+	}
+
+	t.Setenv("ZINKTRAY_SENDMAIL_ADDR", "2626")
+
+	if _, err := LoadSendmail("sendmail"); err == nil {
+		t.Fatal("Loading is expected to fail sendmail options validation")
+	}
+}
+
+func writeFile(t *testing.T, name string, contents string) string {
+	var path = filepath.Join(t.TempDir(), name)
+
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("Cannot write file \"%s\": %s", path, err)
+	}
+
+	return path
+}
+
+func TestLoadRetention(t *testing.T) {
+	var path = writeFile(
+		t,
+		"config.yaml",
+		"retention:\n  max_age: 24h\n  mailbox:\n    max_messages: 100\n  mailboxes:\n    load:\n      max_bytes: 1048576\n",
+	)
+
+	cfg, err := Load("zinktray", []string{"-config", path, "-retention-max-messages", "1000"}, io.Discard)
+
+	if err != nil {
+		t.Fatalf("Unexpected error upon loading configuration: %s", err)
+	}
+
+	if global := cfg.Retention.Global(); global != (RetentionLimits{MaxMessages: 1000, MaxAge: Duration(24 * time.Hour)}) {
+		t.Errorf("Global limits do not match: got %+v", global)
+	}
+
+	if limits := cfg.Retention.ForMailbox("signup"); limits != (RetentionLimits{MaxMessages: 100}) {
+		t.Errorf("Mailbox limits do not match: got %+v", limits)
+	}
+
+	if limits := cfg.Retention.ForMailbox("load"); limits != (RetentionLimits{MaxBytes: 1048576}) {
+		t.Errorf("Overridden mailbox limits do not match: got %+v", limits)
+	}
+
+	if !cfg.Retention.IsEnabled() || Default().Retention.IsEnabled() {
+		t.Error("Retention is expected to be enabled by limits only")
+	}
+
+	cfg.Retention.Mailboxes["load"] = RetentionLimits{MaxAge: -1}
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("Negative limit is expected to be invalid")
+	}
+}
+
+func TestLoadWebhooks(t *testing.T) {
+	var path = writeFile(
+		t,
+		"config.yaml",
+		"webhooks:\n  endpoints:\n    - url: \"http://localhost/hook\"\n      mailboxes: [\"signup\"]\n      secret: \"secret\"\n",
+	)
+
+	cfg, err := Load("zinktray", []string{"-config", path, "-webhooks-max-attempts", "3"}, io.Discard)
+
+	if err != nil {
+		t.Fatalf("Unexpected error upon loading configuration: %s", err)
+	}
+
+	if len(cfg.Webhooks.Endpoints) != 1 {
+		t.Fatalf("Unexpected webhooks: %+v", cfg.Webhooks.Endpoints)
+	}
+
+	var endpoint = cfg.Webhooks.Endpoints[0]
+
+	if endpoint.URL != "http://localhost/hook" || len(endpoint.Mailboxes) != 1 || endpoint.Secret != "secret" {
+		t.Errorf("Webhook does not match: got %+v", endpoint)
+	}
+
+	if cfg.Webhooks.MaxAttempts != 3 {
+		t.Errorf("Unexpected max attempts: got %d, expected 3", cfg.Webhooks.MaxAttempts)
+	}
+
+	cfg.Webhooks.Endpoints[0].URL = "localhost/hook"
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("Relative webhook URL is expected to be invalid")
+	}
+}
+
+func TestLoadClient(t *testing.T) {
+	t.Setenv("ZINKTRAY_SMTP_ADDR", "2525")
+	t.Setenv("ZINKTRAY_STORAGE_BACKEND", "unknown")
+	t.Setenv("ZINKTRAY_API_ADDR", "127.0.0.1:9090")
+
+	cfg, err := LoadClient("mailboxes")
+
+	if err != nil {
+		t.Fatalf("Invalid server options are not expected to fail client configuration: %s", err)
+	}
+
+	if cfg.API.Addr != "127.0.0.1:9090" {
+		t.Errorf("API address does not match: got \"%s\", expected \"%s\"", cfg.API.Addr, "127.0.0.1:9090")
+	}
+
+	t.Setenv("ZINKTRAY_API_ADDR", "9090")
+
+	if _, err := LoadClient("mailboxes"); err == nil {
+		t.Fatal("Loading is expected to fail HTTP API address validation")
+	}
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix is prepended to every environment variable name recognized by configuration loader.
+const envPrefix = "ZINKTRAY_"
+
+// configFileOption contains name of the option pointing to configuration file.
+const configFileOption = "config"
+
+// ErrUnknownFormat is returned upon loading configuration file of unsupported format.
+var ErrUnknownFormat = errors.New("unknown configuration file format")
+
+// Load assembles application configuration.
+//
+// Options are taken from the following sources, each overriding the previous one:
+//   - default values;
+//   - configuration file (YAML, TOML or JSON) given by "-config" flag or ZINKTRAY_CONFIG environment variable;
+//   - environment variables (e.g. ZINKTRAY_SMTP_ADDR for "smtp-addr" option);
+//   - command-line flags (e.g. "-smtp-addr").
+//
+// Returned configuration is validated as server configuration. Returns flag.ErrHelp when usage information has been
+// requested.
+func Load(name string, args []string, output io.Writer) (*Config, error) {
+	return load(name, args, output, (*Config).Validate)
+}
+
+// LoadClient assembles configuration of commands talking to a running server through HTTP API out of configuration
+// file and environment variables, the same way Load does.
+//
+// Only HTTP API address is validated, so that the commands work regardless of other server options.
+func LoadClient(name string) (*Config, error) {
+	return load(name, nil, io.Discard, (*Config).ValidateClient)
+}
+
+// LoadSendmail assembles configuration of sendmail-compatible command out of configuration file and environment
+// variables, the same way Load does.
+//
+// Only sendmail options are validated, so that the command works regardless of server options.
+func LoadSendmail(name string) (*Config, error) {
+	return load(name, nil, io.Discard, (*Config).ValidateSendmail)
+}
+
+// load assembles application configuration and validates it with validate function.
+func load(name string, args []string, output io.Writer, validate func(cfg *Config) error) (*Config, error) {
+	flags, configFile, err := parseFlags(name, args, output)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if configFile == "" {
+		configFile = os.Getenv(envName(configFileOption))
+	}
+
+	cfg := Default()
+
+	if configFile != "" {
+		if err := loadFile(cfg, configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, opt := range options(cfg) {
+		if value, ok := os.LookupEnv(envName(opt.name)); ok {
+			if err := opt.value.Set(value); err != nil {
+				return nil, fmt.Errorf("invalid value %q of environment variable %s: %w", value, envName(opt.name), err)
+			}
+		}
+	}
+
+	for _, opt := range options(cfg) {
+		if value, ok := flags[opt.name]; ok {
+			if err := opt.value.Set(value); err != nil {
+				return nil, fmt.Errorf("invalid value %q of flag -%s: %w", value, opt.name, err)
+			}
+		}
+	}
+
+	if err := validate(cfg); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// parseFlags parses command-line arguments.
+//
+// Returns values of explicitly set option flags mapped by option name, and path to configuration file if given.
+func parseFlags(name string, args []string, output io.Writer) (map[string]string, string, error) {
+	var configFile string
+
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	flagSet.SetOutput(output)
+
+	flagSet.StringVar(
+		&configFile,
+		configFileOption,
+		"",
+		fmt.Sprintf("path to YAML, TOML or JSON configuration `file` (env %s)", envName(configFileOption)),
+	)
+
+	for _, opt := range options(Default()) {
+		flagSet.Var(opt.value, opt.name, fmt.Sprintf("%s (env %s)", opt.usage, envName(opt.name)))
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		return nil, "", err
+	}
+
+	if flagSet.NArg() > 0 {
+		return nil, "", fmt.Errorf("unexpected argument %q", flagSet.Arg(0))
+	}
+
+	flags := make(map[string]string)
+
+	flagSet.Visit(func(f *flag.Flag) {
+		if f.Name != configFileOption {
+			flags[f.Name] = f.Value.String()
+		}
+	})
+
+	return flags, configFile, nil
+}
+
+// loadFile reads configuration file into cfg.
+//
+// File format is determined by file extension.
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return fmt.Errorf("cannot read configuration file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+
+		err = decoder.Decode(cfg)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+
+		if err = decoder.Decode(cfg); errors.Is(err, io.EOF) {
+			// Empty file is a valid configuration.
+			err = nil
+		}
+	case ".toml":
+		var meta toml.MetaData
+
+		if meta, err = toml.Decode(string(data), cfg); err == nil {
+			if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+				err = fmt.Errorf("unknown option %q", undecoded[0].String())
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot decode configuration file %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// envName returns name of environment variable corresponding to option name.
+func envName(optionName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(optionName, "-", "_"))
+}
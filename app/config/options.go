@@ -0,0 +1,168 @@
+package config
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+)
+
+// option describes single configuration option settable via environment variable and command-line flag.
+type option struct {
+	// name contains option name used as flag name and to derive environment variable name.
+	name string
+
+	// usage contains short description of the option.
+	usage string
+
+	// value provides access to configuration field holding the option.
+	value flag.Value
+}
+
+// options lists every configuration option settable via environment variables and command-line flags.
+//
+// Option values are bound to respective fields of cfg.
+func options(cfg *Config) []option {
+	return []option{
+		{"smtp-addr", "SMTP server listen `address`", (*stringValue)(&cfg.SMTP.Addr)},
+		{"smtp-domain", "SMTP server `domain` name", (*stringValue)(&cfg.SMTP.Domain)},
+		{"smtp-read-timeout", "SMTP command read `timeout`", &cfg.SMTP.ReadTimeout},
+		{"smtp-write-timeout", "SMTP response write `timeout`", &cfg.SMTP.WriteTimeout},
+		{"smtp-max-message-bytes", "maximum accepted message size in `bytes`", (*int64Value)(&cfg.SMTP.MaxMessageBytes)},
+		{"smtp-max-recipients", "maximum `number` of recipients per message, 0 for no limit", (*intValue)(&cfg.SMTP.MaxRecipients)},
+		{"smtp-routing", "mailbox routing `mode`: auth, address, domain or tag", (*stringValue)(&cfg.SMTP.Routing)},
+		{"smtp-starttls", "enable STARTTLS extension", (*boolValue)(&cfg.SMTP.StartTLS)},
+		{"smtp-tls-addr", "SMTPS (implicit TLS) listen `address`, empty to disable", (*stringValue)(&cfg.SMTP.TLSAddr)},
+		{"pop3-addr", "POP3 server listen `address`, empty to disable", (*stringValue)(&cfg.POP3.Addr)},
+		{"pop3-timeout", "POP3 session inactivity `timeout`", &cfg.POP3.Timeout},
+		{"pop3-starttls", "enable POP3 STLS command", (*boolValue)(&cfg.POP3.StartTLS)},
+		{"imap-addr", "IMAP server listen `address`, empty to disable", (*stringValue)(&cfg.IMAP.Addr)},
+		{"imap-starttls", "enable IMAP STARTTLS command", (*boolValue)(&cfg.IMAP.StartTLS)},
+		{"api-addr", "HTTP API server listen `address`", (*stringValue)(&cfg.API.Addr)},
+		{"tls-cert-file", "PEM-encoded TLS certificate chain `file`, empty to generate", (*stringValue)(&cfg.TLS.CertFile)},
+		{"tls-key-file", "PEM-encoded TLS private key `file`", (*stringValue)(&cfg.TLS.KeyFile)},
+		{"tls-hosts", "comma-separated `hosts` generated TLS certificate is valid for", (*listValue)(&cfg.TLS.Hosts)},
+		{"storage-backend", "message storage `backend`: memory or file", (*stringValue)(&cfg.Storage.Backend)},
+		{"storage-path", "`directory` file storage keeps messages in", (*stringValue)(&cfg.Storage.Path)},
+		{"retention-interval", "`period` of evicting messages exceeding retention limits", &cfg.Retention.Interval},
+		{"retention-max-messages", "maximum `number` of messages in all mailboxes, 0 for no limit", (*intValue)(&cfg.Retention.MaxMessages)},
+		{"retention-max-bytes", "maximum total size of messages in all mailboxes in `bytes`, 0 for no limit", (*int64Value)(&cfg.Retention.MaxBytes)},
+		{"retention-max-age", "maximum `age` of messages, 0 for no limit", &cfg.Retention.MaxAge},
+		{"retention-mailbox-max-messages", "maximum `number` of messages per mailbox, 0 for no limit", (*intValue)(&cfg.Retention.Mailbox.MaxMessages)},
+		{"retention-mailbox-max-bytes", "maximum total size of messages per mailbox in `bytes`, 0 for no limit", (*int64Value)(&cfg.Retention.Mailbox.MaxBytes)},
+		{"retention-mailbox-max-age", "maximum `age` of messages per mailbox, 0 for no limit", &cfg.Retention.Mailbox.MaxAge},
+		{"webhooks-timeout", "webhook notification request `timeout`", &cfg.Webhooks.Timeout},
+		{"webhooks-max-attempts", "maximum `number` of attempts to deliver webhook notification", (*intValue)(&cfg.Webhooks.MaxAttempts)},
+		{"webhooks-retry-interval", "`delay` before the first retry of webhook notification, doubled upon every next retry", &cfg.Webhooks.RetryInterval},
+		{"webhooks-queue-size", "maximum `number` of webhook notifications awaiting delivery per webhook", (*intValue)(&cfg.Webhooks.QueueSize)},
+		{"relay-addr", "upstream SMTP relay `address` messages are released to, empty to disable", (*stringValue)(&cfg.Relay.Addr)},
+		{"relay-tls", "upstream SMTP relay TLS `mode`: none, starttls or tls", (*stringValue)(&cfg.Relay.TLS)},
+		{"relay-tls-skip-verify", "accept upstream SMTP relay certificate without verification", (*boolValue)(&cfg.Relay.TLSSkipVerify)},
+		{"relay-username", "upstream SMTP relay authentication `username`, empty to disable", (*stringValue)(&cfg.Relay.Username)},
+		{"relay-password", "upstream SMTP relay authentication `password`", (*stringValue)(&cfg.Relay.Password)},
+		{"relay-allowed-domains", "comma-separated recipient `domains` messages may be released to", (*listValue)(&cfg.Relay.AllowedDomains)},
+		{"sendmail-addr", "SMTP server `address` sendmail command submits messages to", (*stringValue)(&cfg.Sendmail.Addr)},
+		{"sendmail-username", "sendmail command authentication `username`, empty to submit anonymously", (*stringValue)(&cfg.Sendmail.Username)},
+		{"sendmail-password", "sendmail command authentication `password`", (*stringValue)(&cfg.Sendmail.Password)},
+	}
+}
+
+// Set parses duration value from its human-readable form.
+func (d *Duration) Set(value string) error {
+	return d.UnmarshalText([]byte(value))
+}
+
+// String returns human-readable form of duration.
+func (d *Duration) String() string {
+	text, _ := d.MarshalText()
+
+	return string(text)
+}
+
+type stringValue string
+
+func (v *stringValue) Set(value string) error {
+	*v = stringValue(value)
+
+	return nil
+}
+
+func (v *stringValue) String() string {
+	return string(*v)
+}
+
+type intValue int
+
+func (v *intValue) Set(value string) error {
+	parsed, err := strconv.Atoi(value)
+
+	if err != nil {
+		return err
+	}
+
+	*v = intValue(parsed)
+
+	return nil
+}
+
+func (v *intValue) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+type int64Value int64
+
+func (v *int64Value) Set(value string) error {
+	parsed, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil {
+		return err
+	}
+
+	*v = int64Value(parsed)
+
+	return nil
+}
+
+func (v *int64Value) String() string {
+	return strconv.FormatInt(int64(*v), 10)
+}
+
+type boolValue bool
+
+func (v *boolValue) Set(value string) error {
+	parsed, err := strconv.ParseBool(value)
+
+	if err != nil {
+		return err
+	}
+
+	*v = boolValue(parsed)
+
+	return nil
+}
+
+func (v *boolValue) String() string {
+	return strconv.FormatBool(bool(*v))
+}
+
+// IsBoolFlag allows boolean flags to be given without value.
+func (v *boolValue) IsBoolFlag() bool {
+	return true
+}
+
+type listValue []string
+
+func (v *listValue) Set(value string) error {
+	*v = nil
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*v = append(*v, item)
+		}
+	}
+
+	return nil
+}
+
+func (v *listValue) String() string {
+	return strings.Join(*v, ",")
+}
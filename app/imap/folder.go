@@ -0,0 +1,460 @@
+package imap
+
+import (
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"zinktray/app/storage"
+
+	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapserver"
+)
+
+// inboxName contains name of the only folder every mailbox is exposed as.
+const inboxName = "INBOX"
+
+// folderDelim contains hierarchy delimiter of folder names.
+const folderDelim rune = '/'
+
+// folder represents IMAP view of a mailbox.
+//
+// UIDs are assigned to stored messages in order of addition and remain stable as long as the folder lives.
+type folder struct {
+	// mailboxID contains ID of the mailbox exposed.
+	mailboxID string
+
+	// uidValidity contains UIDVALIDITY value of the folder.
+	uidValidity uint32
+
+	// tracker dispatches folder updates to sessions.
+	tracker *imapserver.MailboxTracker
+
+	mutex sync.Mutex
+
+	// messages contains messages of the folder, oldest first. Index of a message is its sequence number minus one.
+	messages []*folderMessage
+
+	// uids maps message ID to UID assigned.
+	uids map[string]imap.UID
+
+	// uidNext contains UID to be assigned to the next message.
+	uidNext imap.UID
+}
+
+// folderMessage represents information on message contained in folder.
+type folderMessage struct {
+	// id contains ID of stored message.
+	id string
+
+	// uid contains UID assigned to the message.
+	uid imap.UID
+
+	// receivedAt contains time message has been received at.
+	receivedAt time.Time
+
+	// flags contains message flags in canonical form.
+	flags map[imap.Flag]struct{}
+}
+
+// folderEntry contains copy of folder message information along with its sequence number.
+type folderEntry struct {
+	// seqNum contains actual sequence number of the message at the time of copying.
+	seqNum uint32
+
+	// msg contains copy of message information.
+	msg folderMessage
+}
+
+// sync brings folder up to date with messages stored in the mailbox.
+//
+// Messages deleted from storage are expunged, and newly stored messages are assigned UIDs.
+func (f *folder) sync(store storage.Storage) {
+	f.mutex.Lock()
+
+	defer f.mutex.Unlock()
+
+	stored := store.GetMessages(f.mailboxID)
+	present := make(map[string]struct{}, len(stored))
+
+	for _, msg := range stored {
+		present[msg.ID] = struct{}{}
+	}
+
+	// Expunge in reverse order to keep sequence numbers of preceding messages intact.
+	for i := len(f.messages) - 1; i >= 0; i-- {
+		if _, ok := present[f.messages[i].id]; !ok {
+			delete(f.uids, f.messages[i].id)
+
+			f.messages = append(f.messages[:i], f.messages[i+1:]...)
+			f.tracker.QueueExpunge(uint32(i) + 1)
+		}
+	}
+
+	count := len(f.messages)
+
+	// Stored messages go newest first.
+	for i := len(stored) - 1; i >= 0; i-- {
+		if _, ok := f.uids[stored[i].ID]; ok {
+			continue
+		}
+
+		f.uids[stored[i].ID] = f.uidNext
+		f.messages = append(f.messages, &folderMessage{
+			id:         stored[i].ID,
+			uid:        f.uidNext,
+			receivedAt: stored[i].ReceivedAt,
+			flags:      make(map[imap.Flag]struct{}),
+		})
+
+		f.uidNext++
+	}
+
+	if len(f.messages) > count {
+		f.tracker.QueueNumMessages(uint32(len(f.messages)))
+	}
+}
+
+// selectData returns data for SELECT and EXAMINE commands.
+func (f *folder) selectData() *imap.SelectData {
+	f.mutex.Lock()
+
+	defer f.mutex.Unlock()
+
+	data := &imap.SelectData{
+		Flags:          systemFlags,
+		PermanentFlags: append(append([]imap.Flag{}, systemFlags...), imap.FlagWildcard),
+		NumMessages:    uint32(len(f.messages)),
+		UIDNext:        f.uidNext,
+		UIDValidity:    f.uidValidity,
+	}
+
+	for i, msg := range f.messages {
+		if !msg.hasFlag(imap.FlagSeen) {
+			data.FirstUnseenSeqNum = uint32(i) + 1
+
+			break
+		}
+	}
+
+	return data
+}
+
+// statusData returns data for STATUS command.
+func (f *folder) statusData(options *imap.StatusOptions) *imap.StatusData {
+	f.mutex.Lock()
+
+	defer f.mutex.Unlock()
+
+	data := &imap.StatusData{
+		Mailbox: inboxName,
+	}
+
+	if options.NumMessages {
+		count := uint32(len(f.messages))
+		data.NumMessages = &count
+	}
+
+	if options.UIDNext {
+		data.UIDNext = f.uidNext
+	}
+
+	if options.UIDValidity {
+		data.UIDValidity = f.uidValidity
+	}
+
+	if options.NumUnseen {
+		count := uint32(0)
+
+		for _, msg := range f.messages {
+			if !msg.hasFlag(imap.FlagSeen) {
+				count++
+			}
+		}
+
+		data.NumUnseen = &count
+	}
+
+	if options.NumRecent {
+		count := uint32(0)
+		data.NumRecent = &count
+	}
+
+	return data
+}
+
+// deletedMessageIDs returns IDs of messages flagged as deleted. When uids is given only those messages are considered.
+func (f *folder) deletedMessageIDs(uids *imap.UIDSet) []string {
+	f.mutex.Lock()
+
+	defer f.mutex.Unlock()
+
+	var ids []string
+
+	for _, msg := range f.messages {
+		if uids != nil && !uids.Contains(msg.uid) {
+			continue
+		}
+
+		if msg.hasFlag(imap.FlagDeleted) {
+			ids = append(ids, msg.id)
+		}
+	}
+
+	return ids
+}
+
+// forEach calls fn for every message with sequence number or UID contained in numSet, or every message when numSet is
+// nil.
+//
+// Sequence numbers in numSet are expected as seen by session, while fn is given actual ones. Folder is locked while fn
+// is being called, so that fn must neither load messages nor write to the client.
+func (f *folder) forEach(
+	session *imapserver.SessionTracker,
+	numSet imap.NumSet,
+	fn func(seqNum uint32, msg *folderMessage),
+) {
+	f.mutex.Lock()
+
+	defer f.mutex.Unlock()
+
+	numSet = f.staticNumSet(numSet)
+
+	for i, msg := range f.messages {
+		seqNum := uint32(i) + 1
+
+		switch numSet := numSet.(type) {
+		case imap.SeqSet:
+			if sessionSeqNum := session.EncodeSeqNum(seqNum); sessionSeqNum == 0 || !numSet.Contains(sessionSeqNum) {
+				continue
+			}
+		case imap.UIDSet:
+			if !numSet.Contains(msg.uid) {
+				continue
+			}
+		}
+
+		fn(seqNum, msg)
+	}
+}
+
+// snapshot returns copies of messages with sequence number or UID contained in numSet, or of every message when numSet
+// is nil, along with their actual sequence numbers.
+//
+// Folder is locked while messages are copied only, so that messages could be loaded and written to a slow client
+// without blocking the folder.
+func (f *folder) snapshot(session *imapserver.SessionTracker, numSet imap.NumSet) []folderEntry {
+	var entries []folderEntry
+
+	f.forEach(session, numSet, func(seqNum uint32, msg *folderMessage) {
+		entry := folderEntry{seqNum: seqNum, msg: *msg}
+		entry.msg.flags = maps.Clone(msg.flags)
+
+		entries = append(entries, entry)
+	})
+
+	return entries
+}
+
+// markSeen flags message with provided UID as seen, reporting the change to sessions other than provided one.
+//
+// Returns updated message flags, or nil when message has been expunged meanwhile.
+func (f *folder) markSeen(session *imapserver.SessionTracker, uid imap.UID) []imap.Flag {
+	f.mutex.Lock()
+
+	defer f.mutex.Unlock()
+
+	// Messages are kept in order of UIDs assigned.
+	i := sort.Search(len(f.messages), func(i int) bool {
+		return f.messages[i].uid >= uid
+	})
+
+	if i == len(f.messages) || f.messages[i].uid != uid {
+		return nil
+	}
+
+	msg := f.messages[i]
+	msg.flags[imap.FlagSeen] = struct{}{}
+
+	f.tracker.QueueMessageFlags(uint32(i)+1, msg.uid, msg.flagList(), session)
+
+	return msg.flagList()
+}
+
+// staticSearchCriteria replaces "*" symbol in sequence number and UID criteria with actual values.
+func (f *folder) staticSearchCriteria(criteria *imap.SearchCriteria) {
+	f.mutex.Lock()
+
+	defer f.mutex.Unlock()
+
+	f.staticSearchCriteriaLocked(criteria)
+}
+
+func (f *folder) staticSearchCriteriaLocked(criteria *imap.SearchCriteria) {
+	for i := range criteria.SeqNum {
+		criteria.SeqNum[i] = f.staticNumSet(criteria.SeqNum[i]).(imap.SeqSet)
+	}
+
+	for i := range criteria.UID {
+		criteria.UID[i] = f.staticNumSet(criteria.UID[i]).(imap.UIDSet)
+	}
+
+	for i := range criteria.Not {
+		f.staticSearchCriteriaLocked(&criteria.Not[i])
+	}
+
+	for i := range criteria.Or {
+		f.staticSearchCriteriaLocked(&criteria.Or[i][0])
+		f.staticSearchCriteriaLocked(&criteria.Or[i][1])
+	}
+}
+
+// staticNumSet replaces "*" symbol standing for the largest sequence number or UID with actual value.
+func (f *folder) staticNumSet(numSet imap.NumSet) imap.NumSet {
+	switch numSet := numSet.(type) {
+	case imap.SeqSet:
+		last := uint32(len(f.messages))
+
+		for i := range numSet {
+			staticNumRange(&numSet[i].Start, &numSet[i].Stop, last)
+		}
+	case imap.UIDSet:
+		last := uint32(f.uidNext) - 1
+
+		for i := range numSet {
+			staticNumRange((*uint32)(&numSet[i].Start), (*uint32)(&numSet[i].Stop), last)
+		}
+	}
+
+	return numSet
+}
+
+// staticNumRange replaces zero range bounds standing for "*" symbol with last value.
+func staticNumRange(start *uint32, stop *uint32, last uint32) {
+	dynamic := false
+
+	if *start == 0 {
+		*start = last
+		dynamic = true
+	}
+
+	if *stop == 0 {
+		*stop = last
+		dynamic = true
+	}
+
+	if dynamic && *start > *stop {
+		*start, *stop = *stop, *start
+	}
+}
+
+// systemFlags lists flags defined by IMAP protocol which are always applicable to messages.
+var systemFlags = []imap.Flag{
+	imap.FlagSeen,
+	imap.FlagAnswered,
+	imap.FlagFlagged,
+	imap.FlagDeleted,
+	imap.FlagDraft,
+}
+
+// hasFlag tells whether message is flagged with flag.
+func (msg *folderMessage) hasFlag(flag imap.Flag) bool {
+	_, ok := msg.flags[canonicalFlag(flag)]
+
+	return ok
+}
+
+// flagList returns message flags sorted alphabetically.
+func (msg *folderMessage) flagList() []imap.Flag {
+	flags := make([]imap.Flag, 0, len(msg.flags))
+
+	for flag := range msg.flags {
+		flags = append(flags, flag)
+	}
+
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i] < flags[j]
+	})
+
+	return flags
+}
+
+// store changes message flags.
+func (msg *folderMessage) store(flags *imap.StoreFlags) {
+	if flags.Op == imap.StoreFlagsSet {
+		msg.flags = make(map[imap.Flag]struct{})
+	}
+
+	for _, flag := range flags.Flags {
+		if flags.Op == imap.StoreFlagsDel {
+			delete(msg.flags, canonicalFlag(flag))
+		} else {
+			msg.flags[canonicalFlag(flag)] = struct{}{}
+		}
+	}
+}
+
+// canonicalFlag returns canonical form of flag. System flags are case-insensitive, keywords are kept as is.
+func canonicalFlag(flag imap.Flag) imap.Flag {
+	for _, systemFlag := range systemFlags {
+		if strings.EqualFold(string(flag), string(systemFlag)) {
+			return systemFlag
+		}
+	}
+
+	return flag
+}
+
+// folderRegistry keeps folders of every mailbox accessed via IMAP.
+type folderRegistry struct {
+	mutex sync.Mutex
+
+	// folders maps mailbox ID to its folder.
+	folders map[string]*folder
+
+	// uidValidity contains UIDVALIDITY value assigned to the last folder created.
+	uidValidity uint32
+}
+
+// get returns folder of mailbox with provided ID, creating one when necessary.
+func (registry *folderRegistry) get(mailboxID string) *folder {
+	registry.mutex.Lock()
+
+	defer registry.mutex.Unlock()
+
+	if f, ok := registry.folders[mailboxID]; ok {
+		return f
+	}
+
+	// UIDVALIDITY must change whenever UIDs are reassigned, which happens upon restart.
+	registry.uidValidity = max(registry.uidValidity+1, uint32(time.Now().Unix()))
+
+	f := &folder{
+		mailboxID:   mailboxID,
+		uidValidity: registry.uidValidity,
+		tracker:     imapserver.NewMailboxTracker(0),
+		uids:        make(map[string]imap.UID),
+		uidNext:     1,
+	}
+
+	registry.folders[mailboxID] = f
+
+	return f
+}
+
+// lookup returns folder of mailbox with provided ID. Returns nil when there is no such folder.
+func (registry *folderRegistry) lookup(mailboxID string) *folder {
+	registry.mutex.Lock()
+
+	defer registry.mutex.Unlock()
+
+	return registry.folders[mailboxID]
+}
+
+// newFolderRegistry creates new folder registry structure.
+func newFolderRegistry() *folderRegistry {
+	return &folderRegistry{
+		folders: make(map[string]*folder),
+	}
+}
@@ -0,0 +1,245 @@
+package imap
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/mail"
+	"strings"
+	"time"
+	"zinktray/app/message/parse"
+
+	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapserver"
+	"github.com/emersion/go-message/textproto"
+)
+
+// messageData represents contents of stored message as seen by IMAP clients.
+type messageData struct {
+	// raw contains raw message contents with CRLF line endings.
+	raw []byte
+
+	// contents contains decoded message contents. Parsed on demand, use getContents to access.
+	contents *parse.ContentInfo
+}
+
+// fetch writes message data requested by client.
+func (data *messageData) fetch(w *imapserver.FetchResponseWriter, msg *folderMessage, options *imap.FetchOptions) error {
+	w.WriteUID(msg.uid)
+
+	if options.Flags {
+		w.WriteFlags(msg.flagList())
+	}
+
+	if options.InternalDate {
+		w.WriteInternalDate(msg.receivedAt)
+	}
+
+	if options.RFC822Size {
+		w.WriteRFC822Size(int64(len(data.raw)))
+	}
+
+	if options.Envelope {
+		if header, err := data.header(); err == nil {
+			w.WriteEnvelope(imapserver.ExtractEnvelope(header))
+		}
+	}
+
+	if options.BodyStructure != nil {
+		w.WriteBodyStructure(imapserver.ExtractBodyStructure(bytes.NewReader(data.raw)))
+	}
+
+	for _, section := range options.BodySection {
+		contents := imapserver.ExtractBodySection(bytes.NewReader(data.raw), section)
+
+		if err := writeSection(w.WriteBodySection(section, int64(len(contents))), contents); err != nil {
+			return err
+		}
+	}
+
+	for _, section := range options.BinarySection {
+		contents := imapserver.ExtractBinarySection(bytes.NewReader(data.raw), section)
+
+		if err := writeSection(w.WriteBinarySection(section, int64(len(contents))), contents); err != nil {
+			return err
+		}
+	}
+
+	for _, section := range options.BinarySectionSize {
+		w.WriteBinarySectionSize(section, imapserver.ExtractBinarySectionSize(bytes.NewReader(data.raw), section))
+	}
+
+	return w.Close()
+}
+
+// search tells whether message satisfies criteria. seqNum contains message sequence number as seen by session.
+func (data *messageData) search(seqNum uint32, msg *folderMessage, criteria *imap.SearchCriteria) bool {
+	for _, seqSet := range criteria.SeqNum {
+		if seqNum == 0 || !seqSet.Contains(seqNum) {
+			return false
+		}
+	}
+
+	for _, uidSet := range criteria.UID {
+		if !uidSet.Contains(msg.uid) {
+			return false
+		}
+	}
+
+	if !matchDate(msg.receivedAt, criteria.Since, criteria.Before) {
+		return false
+	}
+
+	for _, flag := range criteria.Flag {
+		if !msg.hasFlag(flag) {
+			return false
+		}
+	}
+
+	for _, flag := range criteria.NotFlag {
+		if msg.hasFlag(flag) {
+			return false
+		}
+	}
+
+	if criteria.Larger != 0 && int64(len(data.raw)) <= criteria.Larger {
+		return false
+	}
+
+	if criteria.Smaller != 0 && int64(len(data.raw)) >= criteria.Smaller {
+		return false
+	}
+
+	if len(criteria.Header) > 0 || !criteria.SentSince.IsZero() || !criteria.SentBefore.IsZero() {
+		header, err := data.header()
+
+		if err != nil {
+			return false
+		}
+
+		for _, field := range criteria.Header {
+			if !matchHeader(header.Values(field.Key), field.Value) {
+				return false
+			}
+		}
+
+		if !criteria.SentSince.IsZero() || !criteria.SentBefore.IsZero() {
+			sentAt, err := mail.ParseDate(header.Get("Date"))
+
+			if err != nil || !matchDate(sentAt, criteria.SentSince, criteria.SentBefore) {
+				return false
+			}
+		}
+	}
+
+	for _, text := range criteria.Text {
+		if !containsFold(string(data.raw), text) && !data.bodyContains(text) {
+			return false
+		}
+	}
+
+	for _, text := range criteria.Body {
+		if !data.bodyContains(text) {
+			return false
+		}
+	}
+
+	for _, not := range criteria.Not {
+		if data.search(seqNum, msg, &not) {
+			return false
+		}
+	}
+
+	for _, or := range criteria.Or {
+		if !data.search(seqNum, msg, &or[0]) && !data.search(seqNum, msg, &or[1]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// bodyContains tells whether decoded message content contains text, case-insensitive.
+func (data *messageData) bodyContains(text string) bool {
+	contents := data.getContents()
+
+	if contents == nil {
+		return false
+	}
+
+	if contents.Plain != nil && containsFold(*contents.Plain, text) {
+		return true
+	}
+
+	return contents.Html != nil && containsFold(*contents.Html, text)
+}
+
+// header parses message header.
+func (data *messageData) header() (textproto.Header, error) {
+	return textproto.ReadHeader(bufio.NewReader(bytes.NewReader(data.raw)))
+}
+
+// getContents returns decoded message contents. Returns nil when message could not be parsed.
+func (data *messageData) getContents() *parse.ContentInfo {
+	if data.contents == nil {
+		data.contents, _ = parse.ReadContents(string(data.raw))
+	}
+
+	return data.contents
+}
+
+// writeSection writes message section contents.
+func writeSection(w io.WriteCloser, contents []byte) error {
+	if _, err := w.Write(contents); err != nil {
+		w.Close()
+
+		return err
+	}
+
+	return w.Close()
+}
+
+// matchHeader tells whether any header value contains text, case-insensitive. Empty text matches present header.
+func matchHeader(values []string, text string) bool {
+	if text == "" {
+		return len(values) > 0
+	}
+
+	for _, value := range values {
+		if containsFold(value, text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchDate tells whether t falls between since (inclusive) and before (exclusive). Only date parts are compared.
+func matchDate(t time.Time, since time.Time, before time.Time) bool {
+	t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	if !since.IsZero() && t.Before(since) {
+		return false
+	}
+
+	if !before.IsZero() && !t.Before(before) {
+		return false
+	}
+
+	return true
+}
+
+// containsFold tells whether s contains substr, case-insensitive.
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// newMessageData prepares raw message contents for IMAP clients.
+func newMessageData(rawData string) *messageData {
+	// Messages are not required to have been received with CRLF line endings, while IMAP clients expect them.
+	rawData = strings.ReplaceAll(strings.ReplaceAll(rawData, "\r\n", "\n"), "\n", "\r\n")
+
+	return &messageData{
+		raw: []byte(rawData),
+	}
+}
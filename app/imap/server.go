@@ -0,0 +1,111 @@
+package imap
+
+import (
+	"context"
+	"log"
+	"net"
+	"sync"
+	"zinktray/app/certificate"
+	"zinktray/app/config"
+	"zinktray/app/storage"
+
+	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapserver"
+)
+
+// ImapServer structure represents an IMAP server implementation.
+//
+// Handles start and termination of IMAP listener, and keeps folders of accessed mailboxes up to date with storage.
+type ImapServer struct {
+	// certificate contains TLS certificate served by the server.
+	certificate *certificate.Bundle
+
+	// config contains IMAP server configuration.
+	config config.ImapConfig
+
+	// store provides central message storage.
+	store storage.Storage
+
+	// folders contains folders of mailboxes accessed via IMAP.
+	folders *folderRegistry
+}
+
+// Start wires-up IMAP server.
+//
+// Does nothing when no listen address is configured. The listener and every active session are terminated as soon
+// as ctx is cancelled.
+func (srv *ImapServer) Start(ctx context.Context, waitGroup *sync.WaitGroup) {
+	defer waitGroup.Done()
+
+	if srv.config.Addr == "" {
+		return
+	}
+
+	options := &imapserver.Options{
+		NewSession: func(conn *imapserver.Conn) (imapserver.Session, *imapserver.GreetingData, error) {
+			return &imapSession{
+				folders: srv.folders,
+				store:   srv.store,
+			}, nil, nil
+		},
+		Caps:         imap.CapSet{imap.CapIMAP4rev1: {}},
+		InsecureAuth: true,
+	}
+
+	if srv.config.StartTLS {
+		options.TLSConfig = srv.certificate.TLSConfig()
+	}
+
+	server := imapserver.New(options)
+	listener, err := net.Listen("tcp", srv.config.Addr)
+
+	if err != nil {
+		log.Fatalf("IMAP server failed to start: %s", err)
+	}
+
+	// Subscribe before serving, so that sessions are notified of every change.
+	subscription := srv.store.Subscribe(256)
+
+	go srv.watch(subscription)
+
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Fatalf("IMAP server failed: %s", err)
+		}
+	}()
+
+	<-ctx.Done()
+
+	subscription.Close()
+
+	if err := server.Close(); err != nil {
+		log.Fatalf("Cannot shutdown IMAP server: %s", err)
+	}
+}
+
+// watch synchronizes folders with storage upon storage events until subscription is closed.
+//
+// Sessions also synchronize selected folder upon every command, so missed events only delay idling clients.
+func (srv *ImapServer) watch(subscription *storage.Subscription) {
+	for event := range subscription.Events {
+		if event.Type != storage.EventMessageAdded && event.Type != storage.EventMessageDeleted {
+			continue
+		}
+
+		if f := srv.folders.lookup(event.MailboxID); f != nil {
+			f.sync(srv.store)
+		}
+	}
+}
+
+// NewServer creates new IMAP server structure.
+//
+// certificate is only required when STARTTLS command is enabled.
+func NewServer(storage storage.Storage, config config.ImapConfig, certificate *certificate.Bundle) *ImapServer {
+	return &ImapServer{
+		certificate: certificate,
+		config:      config,
+		store:       storage,
+		folders:     newFolderRegistry(),
+	}
+}
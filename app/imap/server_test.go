@@ -0,0 +1,254 @@
+package imap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"zinktray/app/config"
+	"zinktray/app/message"
+	"zinktray/app/storage"
+
+	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapclient"
+	"github.com/emersion/go-sasl"
+)
+
+var rawMessages = []string{
+	"From: Alice <alice@example.com>\r\nSubject: First\r\n\r\nHello world\r\n",
+	"From: Bob <bob@example.com>\nSubject: Second\n\nGoodbye\n",
+}
+
+func TestImap(t *testing.T) {
+	var store = storage.NewMemoryStorage()
+	var cfg = config.Default().IMAP
+	var msgIDs = addMessages(t, store, "test", rawMessages)
+
+	cfg.Addr = "127.0.0.1:1143"
+
+	var cancel = newServer(store, cfg)
+
+	t.Cleanup(cancel)
+
+	var numMessages = make(chan uint32, 8)
+	var expunged = make(chan uint32, 8)
+	var client = newClient(t, cfg.Addr, &imapclient.Options{
+		UnilateralDataHandler: &imapclient.UnilateralDataHandler{
+			Expunge: func(seqNum uint32) {
+				expunged <- seqNum
+			},
+			Mailbox: func(data *imapclient.UnilateralDataMailbox) {
+				if data.NumMessages != nil {
+					numMessages <- *data.NumMessages
+				}
+			},
+		},
+	})
+
+	if err := client.Authenticate(sasl.NewPlainClient("", "test", "secret")); err != nil {
+		t.Fatalf("Cannot authenticate: %s", err)
+	}
+
+	var selected, err = client.Select("INBOX", nil).Wait()
+
+	if err != nil {
+		t.Fatalf("Cannot select folder: %s", err)
+	}
+
+	if selected.NumMessages != 2 || selected.UIDNext != 3 {
+		t.Fatalf("Unexpected folder status: %d messages, next UID %d", selected.NumMessages, selected.UIDNext)
+	}
+
+	t.Run("fetch", func(t *testing.T) {
+		var section = &imap.FetchItemBodySection{}
+		var options = &imap.FetchOptions{
+			UID:           true,
+			Envelope:      true,
+			BodyStructure: &imap.FetchItemBodyStructure{},
+			BodySection:   []*imap.FetchItemBodySection{section},
+		}
+
+		var messages, err = client.Fetch(imap.SeqSetNum(2), options).Collect()
+
+		if err != nil {
+			t.Fatalf("Cannot fetch message: %s", err)
+		}
+
+		if len(messages) != 1 {
+			t.Fatalf("Unexpected number of messages fetched: %d", len(messages))
+		}
+
+		var msg = messages[0]
+
+		if msg.UID != 2 || msg.Envelope == nil || msg.Envelope.Subject != "Second" {
+			t.Errorf("Unexpected message fetched: UID %d, envelope %+v", msg.UID, msg.Envelope)
+		}
+
+		if msg.BodyStructure == nil || msg.BodyStructure.MediaType() != "text/plain" {
+			t.Errorf("Unexpected body structure: %+v", msg.BodyStructure)
+		}
+
+		if body := string(msg.FindBodySection(section)); body != strings.ReplaceAll(rawMessages[1], "\n", "\r\n") {
+			t.Errorf("Unexpected message contents: %q", body)
+		}
+
+		if len(msg.Flags) != 1 || msg.Flags[0] != imap.FlagSeen {
+			t.Errorf("Fetched message is expected to be seen: %v", msg.Flags)
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		var criteria = &imap.SearchCriteria{
+			Header: []imap.SearchCriteriaHeaderField{{Key: "From", Value: "alice"}},
+		}
+
+		var data, err = client.UIDSearch(criteria, nil).Wait()
+
+		if err != nil {
+			t.Fatalf("Cannot search messages: %s", err)
+		}
+
+		if uids := data.AllUIDs(); len(uids) != 1 || uids[0] != 1 {
+			t.Errorf("Unexpected search result: %v", uids)
+		}
+
+		criteria = &imap.SearchCriteria{Body: []string{"goodbye"}, NotFlag: []imap.Flag{imap.FlagDeleted}}
+
+		if data, err = client.Search(criteria, nil).Wait(); err != nil {
+			t.Fatalf("Cannot search messages: %s", err)
+		}
+
+		if seqNums := data.AllSeqNums(); len(seqNums) != 1 || seqNums[0] != 2 {
+			t.Errorf("Unexpected search result: %v", seqNums)
+		}
+	})
+
+	t.Run("idle", func(t *testing.T) {
+		var idle, err = client.Idle()
+
+		if err != nil {
+			t.Fatalf("Cannot start idling: %s", err)
+		}
+
+		addMessages(t, store, "test", []string{"Subject: Third\r\n\r\nNew\r\n"})
+
+		select {
+		case count := <-numMessages:
+			if count != 3 {
+				t.Errorf("Unexpected number of messages: %d", count)
+			}
+		case <-time.After(time.Second):
+			t.Error("Idling client is expected to be notified of new message")
+		}
+
+		if err := idle.Close(); err != nil {
+			t.Fatalf("Cannot stop idling: %s", err)
+		}
+
+		if err := idle.Wait(); err != nil {
+			t.Fatalf("Idling failed: %s", err)
+		}
+	})
+
+	t.Run("expunge", func(t *testing.T) {
+		var flags = &imap.StoreFlags{Op: imap.StoreFlagsAdd, Silent: true, Flags: []imap.Flag{imap.FlagDeleted}}
+
+		if err := client.Store(imap.SeqSetNum(1), flags, nil).Close(); err != nil {
+			t.Fatalf("Cannot flag message: %s", err)
+		}
+
+		var expunged, err = client.Expunge().Collect()
+
+		if err != nil {
+			t.Fatalf("Cannot expunge messages: %s", err)
+		}
+
+		if len(expunged) != 1 || expunged[0] != 1 {
+			t.Errorf("Unexpected messages expunged: %v", expunged)
+		}
+
+		if store.GetMessage(msgIDs[0]) != nil || store.GetMessage(msgIDs[1]) == nil {
+			t.Fatal("Only message flagged as deleted is expected to be deleted")
+		}
+
+		// UIDs of remaining messages are kept.
+		messages, err := client.Fetch(imap.SeqSetNum(1), &imap.FetchOptions{UID: true}).Collect()
+
+		if err != nil || len(messages) != 1 || messages[0].UID != 2 {
+			t.Errorf("Unexpected UID of remaining message: %v %v", messages, err)
+		}
+	})
+
+	t.Run("deleted via storage", func(t *testing.T) {
+		store.DeleteMessage(msgIDs[1])
+
+		if err := client.Noop().Wait(); err != nil {
+			t.Fatalf("Cannot poll updates: %s", err)
+		}
+
+		select {
+		case seqNum := <-expunged:
+			if seqNum != 1 {
+				t.Errorf("Unexpected message expunged: %d", seqNum)
+			}
+		default:
+			t.Error("Client is expected to be notified of message deleted from storage")
+		}
+	})
+
+	if err := client.Logout().Wait(); err != nil {
+		t.Fatalf("Cannot log out: %s", err)
+	}
+}
+
+// addMessages stores messages into mailbox and returns their IDs in order of addition.
+func addMessages(t *testing.T, store storage.Storage, mailboxID string, rawData []string) []string {
+	var ids = make([]string, 0, len(rawData))
+
+	store.AddMailbox(mailboxID)
+
+	for _, data := range rawData {
+		var msg = message.NewMessage(data)
+
+		if err := store.AddMessage(msg, mailboxID); err != nil {
+			t.Fatalf("Cannot store message: %s", err)
+		}
+
+		ids = append(ids, msg.ID)
+	}
+
+	return ids
+}
+
+func newServer(storage storage.Storage, cfg config.ImapConfig) context.CancelFunc {
+	var ctx, cancel = context.WithCancel(context.Background())
+	var server = NewServer(storage, cfg, nil)
+	var wg = &sync.WaitGroup{}
+
+	wg.Add(1)
+
+	go func() {
+		server.Start(ctx, wg)
+	}()
+
+	return cancel
+}
+
+func newClient(t *testing.T, addr string, options *imapclient.Options) *imapclient.Client {
+	var client *imapclient.Client
+	var err error
+
+	for i := 3; i > 0; i-- {
+		if client, err = imapclient.DialInsecure(addr, options); err == nil {
+			t.Cleanup(func() { client.Close() })
+
+			return client
+		}
+
+		time.Sleep(150 * time.Millisecond)
+	}
+
+	panic(fmt.Sprintf("Cannot dial %s: %s", addr, err))
+}
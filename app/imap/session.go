@@ -0,0 +1,347 @@
+package imap
+
+import (
+	"strings"
+	"zinktray/app/storage"
+
+	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapserver"
+)
+
+var errNoSuchFolder = &imap.Error{
+	Type: imap.StatusResponseTypeNo,
+	Code: imap.ResponseCodeNonExistent,
+	Text: "No such mailbox",
+}
+
+var errNotSupported = &imap.Error{
+	Type: imap.StatusResponseTypeNo,
+	Code: imap.ResponseCodeCannot,
+	Text: "Mailbox contents are managed by the server",
+}
+
+var errReadOnly = &imap.Error{
+	Type: imap.StatusResponseTypeNo,
+	Text: "Mailbox is selected read-only",
+}
+
+// imapSession represents information on individual IMAP session.
+type imapSession struct {
+	// folders provides folders of mailboxes.
+	folders *folderRegistry
+
+	// store provides central message storage.
+	store storage.Storage
+
+	// mailboxID contains ID of the mailbox selected by authentication.
+	mailboxID string
+
+	// selected contains folder currently selected. nil when no folder is selected.
+	selected *folder
+
+	// tracker tracks updates of selected folder on behalf of the session.
+	tracker *imapserver.SessionTracker
+
+	// readOnly tells whether selected folder has been selected read-only.
+	readOnly bool
+}
+
+func (session *imapSession) Close() error {
+	return session.Unselect()
+}
+
+func (session *imapSession) Login(username string, password string) error {
+	// Any password is accepted, mailbox is selected by username.
+	if username == "" {
+		return imapserver.ErrAuthFailed
+	}
+
+	mbox := session.store.GetMailbox(username)
+	if mbox == nil {
+		mbox = session.store.AddMailbox(username)
+	}
+
+	session.mailboxID = mbox.ID
+
+	return nil
+}
+
+func (session *imapSession) Select(name string, options *imap.SelectOptions) (*imap.SelectData, error) {
+	f, err := session.folder(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	session.Unselect()
+
+	session.selected = f
+	session.tracker = f.tracker.NewSession()
+	session.readOnly = options != nil && options.ReadOnly
+
+	return f.selectData(), nil
+}
+
+func (session *imapSession) Create(name string, options *imap.CreateOptions) error {
+	return errNotSupported
+}
+
+func (session *imapSession) Delete(name string) error {
+	return errNotSupported
+}
+
+func (session *imapSession) Rename(name string, newName string, options *imap.RenameOptions) error {
+	return errNotSupported
+}
+
+func (session *imapSession) Subscribe(name string) error {
+	// Every folder is always subscribed.
+	_, err := session.folder(name)
+
+	return err
+}
+
+func (session *imapSession) Unsubscribe(name string) error {
+	return errNotSupported
+}
+
+func (session *imapSession) List(w *imapserver.ListWriter, ref string, patterns []string, options *imap.ListOptions) error {
+	if len(patterns) == 0 {
+		return w.WriteList(&imap.ListData{
+			Attrs: []imap.MailboxAttr{imap.MailboxAttrNoSelect},
+			Delim: folderDelim,
+		})
+	}
+
+	for _, pattern := range patterns {
+		if !imapserver.MatchList(inboxName, folderDelim, ref, pattern) {
+			continue
+		}
+
+		data := &imap.ListData{
+			Attrs:   []imap.MailboxAttr{imap.MailboxAttrNoInferiors},
+			Delim:   folderDelim,
+			Mailbox: inboxName,
+		}
+
+		if options.ReturnStatus != nil {
+			f, _ := session.folder(inboxName)
+			data.Status = f.statusData(options.ReturnStatus)
+		}
+
+		return w.WriteList(data)
+	}
+
+	return nil
+}
+
+func (session *imapSession) Status(name string, options *imap.StatusOptions) (*imap.StatusData, error) {
+	f, err := session.folder(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return f.statusData(options), nil
+}
+
+func (session *imapSession) Append(name string, r imap.LiteralReader, options *imap.AppendOptions) (*imap.AppendData, error) {
+	return nil, errNotSupported
+}
+
+func (session *imapSession) Poll(w *imapserver.UpdateWriter, allowExpunge bool) error {
+	if session.selected == nil {
+		return nil
+	}
+
+	session.selected.sync(session.store)
+
+	return session.tracker.Poll(w, allowExpunge)
+}
+
+func (session *imapSession) Idle(w *imapserver.UpdateWriter, stop <-chan struct{}) error {
+	if session.selected == nil {
+		<-stop
+
+		return nil
+	}
+
+	// Updates are queued by the server as soon as storage changes.
+	return session.tracker.Idle(w, stop)
+}
+
+func (session *imapSession) Unselect() error {
+	if session.tracker != nil {
+		session.tracker.Close()
+	}
+
+	session.selected = nil
+	session.tracker = nil
+	session.readOnly = false
+
+	return nil
+}
+
+func (session *imapSession) Expunge(w *imapserver.ExpungeWriter, uids *imap.UIDSet) error {
+	if session.readOnly {
+		return errReadOnly
+	}
+
+	for _, messageID := range session.selected.deletedMessageIDs(uids) {
+		session.store.DeleteMessage(messageID)
+	}
+
+	// Expunged messages are reported to every session, including this one, once folder is synchronized.
+	session.selected.sync(session.store)
+
+	return nil
+}
+
+func (session *imapSession) Search(
+	kind imapserver.NumKind,
+	criteria *imap.SearchCriteria,
+	options *imap.SearchOptions,
+) (*imap.SearchData, error) {
+	var data imap.SearchData
+	var seqSet imap.SeqSet
+	var uidSet imap.UIDSet
+
+	session.selected.staticSearchCriteria(criteria)
+
+	for _, entry := range session.selected.snapshot(session.tracker, nil) {
+		msg := &entry.msg
+		seqNum := session.tracker.EncodeSeqNum(entry.seqNum)
+
+		// Messages session has not been notified of yet have no sequence numbers.
+		if kind == imapserver.NumKindSeq && seqNum == 0 {
+			continue
+		}
+
+		stored := session.store.GetMessage(msg.id)
+
+		if stored == nil || !newMessageData(stored.GetRawData()).search(seqNum, msg, criteria) {
+			continue
+		}
+
+		num := uint32(msg.uid)
+
+		if kind == imapserver.NumKindSeq {
+			num = seqNum
+
+			seqSet.AddNum(seqNum)
+		} else {
+			uidSet.AddNum(msg.uid)
+		}
+
+		if data.Min == 0 || num < data.Min {
+			data.Min = num
+		}
+
+		if num > data.Max {
+			data.Max = num
+		}
+
+		data.Count++
+	}
+
+	if kind == imapserver.NumKindSeq {
+		data.All = seqSet
+	} else {
+		data.All = uidSet
+	}
+
+	return &data, nil
+}
+
+func (session *imapSession) Fetch(w *imapserver.FetchWriter, numSet imap.NumSet, options *imap.FetchOptions) error {
+	markSeen := false
+
+	for _, section := range options.BodySection {
+		markSeen = markSeen || !section.Peek
+	}
+
+	markSeen = markSeen && !session.readOnly
+
+	if markSeen {
+		// Flags are to be reported to this session along with fetched data.
+		options.Flags = true
+	}
+
+	// Messages are loaded and written with folder unlocked, so that a slow client does not block the folder.
+	for _, entry := range session.selected.snapshot(session.tracker, numSet) {
+		msg := &entry.msg
+		stored := session.store.GetMessage(msg.id)
+		sessionSeqNum := session.tracker.EncodeSeqNum(entry.seqNum)
+
+		// Message deleted from storage is skipped until expunge is reported, and message session has not been notified
+		// of yet is skipped until it is.
+		if stored == nil || sessionSeqNum == 0 {
+			continue
+		}
+
+		if markSeen && !msg.hasFlag(imap.FlagSeen) {
+			flags := session.selected.markSeen(session.tracker, msg.uid)
+
+			if flags == nil {
+				continue
+			}
+
+			msg.flags = make(map[imap.Flag]struct{}, len(flags))
+
+			for _, flag := range flags {
+				msg.flags[flag] = struct{}{}
+			}
+		}
+
+		if err := newMessageData(stored.GetRawData()).fetch(w.CreateMessage(sessionSeqNum), msg, options); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (session *imapSession) Store(
+	w *imapserver.FetchWriter,
+	numSet imap.NumSet,
+	flags *imap.StoreFlags,
+	options *imap.StoreOptions,
+) error {
+	if session.readOnly {
+		return errReadOnly
+	}
+
+	var fetchSet imap.UIDSet
+
+	session.selected.forEach(session.tracker, numSet, func(seqNum uint32, msg *folderMessage) {
+		msg.store(flags)
+
+		session.selected.tracker.QueueMessageFlags(seqNum, msg.uid, msg.flagList(), session.tracker)
+
+		fetchSet.AddNum(msg.uid)
+	})
+
+	if flags.Silent || len(fetchSet) == 0 {
+		return nil
+	}
+
+	return session.Fetch(w, fetchSet, &imap.FetchOptions{Flags: true})
+}
+
+func (session *imapSession) Copy(numSet imap.NumSet, dest string) (*imap.CopyData, error) {
+	return nil, errNotSupported
+}
+
+// folder returns folder with provided name synchronized with storage.
+//
+// Every mailbox is exposed as a single folder named INBOX. Returns an error for any other name.
+func (session *imapSession) folder(name string) (*folder, error) {
+	if !strings.EqualFold(name, inboxName) {
+		return nil, errNoSuchFolder
+	}
+
+	f := session.folders.get(session.mailboxID)
+	f.sync(session.store)
+
+	return f, nil
+}
@@ -0,0 +1,255 @@
+// Package compose builds MIME messages out of their structured description.
+package compose
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"time"
+
+	"github.com/emersion/go-message/mail"
+)
+
+// Message describes message to be composed.
+type Message struct {
+	// From contains sender address, optionally with display name (e.g. "Alice <alice@example.com>").
+	From string
+
+	// To contains recipient addresses, optionally with display names.
+	To []string
+
+	// Cc contains carbon copy recipient addresses, optionally with display names.
+	Cc []string
+
+	// Subject contains message subject. Non-ASCII subjects are encoded according to RFC 2047.
+	Subject string
+
+	// Text contains plain-text content. Empty string omits plain-text part.
+	Text string
+
+	// Html contains HTML content. Empty string omits HTML part.
+	Html string
+
+	// Attachments contains files attached to the message.
+	Attachments []Attachment
+}
+
+// Attachment describes file attached to message.
+type Attachment struct {
+	// Filename contains attachment file name.
+	Filename string
+
+	// ContentType contains attachment media type. Guessed by file name extension when empty.
+	ContentType string
+
+	// Data contains attachment contents.
+	Data []byte
+}
+
+// Build composes MIME message with CRLF line endings.
+//
+// Plain-text and HTML contents are combined into multipart/alternative part, and attachments make the message
+// multipart/mixed. Date and Message-ID headers are generated. Returns an error for malformed addresses.
+func Build(msg *Message) (string, error) {
+	header, err := newHeader(msg)
+
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+
+	if len(msg.Attachments) == 0 {
+		err = writeBody(&buffer, header, msg)
+	} else {
+		err = writeMixed(&buffer, header, msg)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("cannot compose message: %w", err)
+	}
+
+	return buffer.String(), nil
+}
+
+// newHeader creates message header.
+func newHeader(msg *Message) (mail.Header, error) {
+	var header mail.Header
+
+	if msg.From != "" {
+		from, err := mail.ParseAddress(msg.From)
+
+		if err != nil {
+			return header, fmt.Errorf("malformed sender address %q: %w", msg.From, err)
+		}
+
+		header.SetAddressList("From", []*mail.Address{from})
+	}
+
+	for _, field := range []struct {
+		key  string
+		list []string
+	}{{"To", msg.To}, {"Cc", msg.Cc}} {
+		addresses := make([]*mail.Address, 0, len(field.list))
+
+		for _, text := range field.list {
+			addr, err := mail.ParseAddress(text)
+
+			if err != nil {
+				return header, fmt.Errorf("malformed recipient address %q: %w", text, err)
+			}
+
+			addresses = append(addresses, addr)
+		}
+
+		if len(addresses) > 0 {
+			header.SetAddressList(field.key, addresses)
+		}
+	}
+
+	header.SetSubject(msg.Subject)
+	header.SetDate(time.Now())
+
+	if err := header.GenerateMessageID(); err != nil {
+		return header, fmt.Errorf("cannot generate message ID: %w", err)
+	}
+
+	return header, nil
+}
+
+// writeBody writes message without attachments.
+func writeBody(w io.Writer, header mail.Header, msg *Message) error {
+	if msg.Text != "" && msg.Html != "" {
+		inline, err := mail.CreateInlineWriter(w, header)
+
+		if err != nil {
+			return err
+		}
+
+		return writeAlternatives(inline, msg)
+	}
+
+	contentType, content := singleContent(msg)
+
+	header.SetContentType(contentType, map[string]string{"charset": "utf-8"})
+
+	part, err := mail.CreateSingleInlineWriter(w, header)
+
+	if err != nil {
+		return err
+	}
+
+	return writePart(part, []byte(content))
+}
+
+// writeMixed writes message with attachments.
+func writeMixed(w io.Writer, header mail.Header, msg *Message) error {
+	mixed, err := mail.CreateWriter(w, header)
+
+	if err != nil {
+		return err
+	}
+
+	if msg.Text != "" && msg.Html != "" {
+		inline, err := mixed.CreateInline()
+
+		if err != nil {
+			return err
+		}
+
+		if err := writeAlternatives(inline, msg); err != nil {
+			return err
+		}
+	} else if msg.Text != "" || msg.Html != "" {
+		var partHeader mail.InlineHeader
+
+		contentType, content := singleContent(msg)
+
+		partHeader.SetContentType(contentType, map[string]string{"charset": "utf-8"})
+
+		part, err := mixed.CreateSingleInline(partHeader)
+
+		if err != nil {
+			return err
+		}
+
+		if err := writePart(part, []byte(content)); err != nil {
+			return err
+		}
+	}
+
+	for _, attachment := range msg.Attachments {
+		var partHeader mail.AttachmentHeader
+
+		contentType := attachment.ContentType
+
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(attachment.Filename))
+		}
+
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		partHeader.Set("Content-Type", contentType)
+		partHeader.SetFilename(attachment.Filename)
+
+		part, err := mixed.CreateAttachment(partHeader)
+
+		if err != nil {
+			return err
+		}
+
+		if err := writePart(part, attachment.Data); err != nil {
+			return err
+		}
+	}
+
+	return mixed.Close()
+}
+
+// singleContent returns media type and contents of the only content part of message lacking alternatives.
+func singleContent(msg *Message) (string, string) {
+	if msg.Html != "" {
+		return "text/html", msg.Html
+	}
+
+	return "text/plain", msg.Text
+}
+
+// writeAlternatives writes plain-text and HTML contents as alternatives, plain-text first.
+func writeAlternatives(inline *mail.InlineWriter, msg *Message) error {
+	for _, alternative := range []struct{ contentType, content string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.Html},
+	} {
+		var partHeader mail.InlineHeader
+
+		partHeader.SetContentType(alternative.contentType, map[string]string{"charset": "utf-8"})
+
+		part, err := inline.CreatePart(partHeader)
+
+		if err != nil {
+			return err
+		}
+
+		if err := writePart(part, []byte(alternative.content)); err != nil {
+			return err
+		}
+	}
+
+	return inline.Close()
+}
+
+// writePart writes part contents.
+func writePart(w io.WriteCloser, contents []byte) error {
+	if _, err := w.Write(contents); err != nil {
+		w.Close()
+
+		return err
+	}
+
+	return w.Close()
+}
@@ -0,0 +1,95 @@
+package compose
+
+import (
+	"strings"
+	"testing"
+	"zinktray/app/message"
+	"zinktray/app/message/parse"
+)
+
+func TestBuild(t *testing.T) {
+	var raw, err = Build(&Message{
+		From:    "Alice <alice@example.com>",
+		To:      []string{"bob@example.com", "Carol <carol@example.com>"},
+		Subject: "Счёт",
+		Text:    "Total: 42",
+		Html:    "<p>Total: <b>42</b></p>",
+		Attachments: []Attachment{
+			{Filename: "invoice.pdf", Data: []byte("%PDF-1.4\n%%EOF\n")},
+			{Filename: "data.bin", ContentType: "application/x-custom", Data: []byte{0, 1, 2}},
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("Cannot build message: %s", err)
+	}
+
+	if strings.Contains(strings.ReplaceAll(raw, "\r\n", ""), "\n") {
+		t.Error("Message is expected to have CRLF line endings")
+	}
+
+	var summary = message.NewMessage(raw).GetSummary()
+
+	if summary.Error != "" {
+		t.Fatalf("Built message cannot be parsed: %s", summary.Error)
+	}
+
+	if summary.Subject != "Счёт" {
+		t.Errorf("Subject does not match: got \"%s\", expected \"%s\"", summary.Subject, "Счёт")
+	}
+
+	if to := strings.Join(summary.To, ","); to != "<bob@example.com>,Carol <carol@example.com>" {
+		t.Errorf("Recipients do not match: got \"%s\"", to)
+	}
+
+	contents, err := parse.ReadContents(raw)
+
+	if err != nil {
+		t.Fatalf("Cannot read message contents: %s", err)
+	}
+
+	if contents.Plain == nil || *contents.Plain != "Total: 42" {
+		t.Errorf("Unexpected plain-text contents: %v", contents.Plain)
+	}
+
+	if contents.Html == nil || *contents.Html != "<p>Total: <b>42</b></p>" {
+		t.Errorf("Unexpected HTML contents: %v", contents.Html)
+	}
+
+	var attachmentsExpected = []parse.Attachment{
+		{Index: 0, Filename: "invoice.pdf", ContentType: "application/pdf", Disposition: "attachment", Data: []byte("%PDF-1.4\n%%EOF\n")},
+		{Index: 1, Filename: "data.bin", ContentType: "application/x-custom", Disposition: "attachment", Data: []byte{0, 1, 2}},
+	}
+
+	if len(contents.Attachments) != len(attachmentsExpected) {
+		t.Fatalf("Attachment count does not match: got %d, expected %d", len(contents.Attachments), len(attachmentsExpected))
+	}
+
+	for i, expected := range attachmentsExpected {
+		var attachment = contents.Attachments[i]
+
+		if attachment.Index != expected.Index ||
+			attachment.Filename != expected.Filename ||
+			attachment.ContentType != expected.ContentType ||
+			attachment.Disposition != expected.Disposition ||
+			string(attachment.Data) != string(expected.Data) {
+			t.Errorf("Attachment does not match at index %d: got %+v, expected %+v", i, *attachment, expected)
+		}
+	}
+}
+
+func TestBuildSinglePart(t *testing.T) {
+	var raw, err = Build(&Message{To: []string{"bob@example.com"}, Html: "<p>Hi</p>"})
+
+	if err != nil {
+		t.Fatalf("Cannot build message: %s", err)
+	}
+
+	if !strings.Contains(raw, "Content-Type: text/html; charset=utf-8\r\n") {
+		t.Errorf("Message without alternatives is expected to be single-part: %q", raw)
+	}
+
+	if _, err := Build(&Message{To: []string{"not an address"}}); err == nil {
+		t.Error("Building is expected to fail on malformed address")
+	}
+}
@@ -0,0 +1,60 @@
+package message
+
+// Envelope structure represents SMTP envelope the message has been delivered with.
+type Envelope struct {
+	// ReturnPath contains sender address given in MAIL FROM command. Empty for null reverse-path ("<>").
+	ReturnPath string
+
+	// Recipients contains every recipient given in RCPT TO commands in the order of appearance.
+	Recipients []Recipient
+
+	// Helo contains client domain name given in HELO/EHLO command.
+	Helo string
+
+	// ClientIP contains IP address of SMTP client.
+	ClientIP string
+
+	// TLS tells whether the message has been transmitted over TLS connection.
+	TLS bool
+
+	// AuthUsername contains username SMTP client authenticated with. Empty for anonymous sessions.
+	AuthUsername string
+
+	// Size contains message size declared with SIZE parameter. Zero when not declared.
+	Size int64
+
+	// Body contains body type declared with BODY parameter (7BIT, 8BITMIME or BINARYMIME).
+	Body string
+
+	// SmtpUtf8 tells whether SMTPUTF8 parameter has been given.
+	SmtpUtf8 bool
+
+	// RequireTLS tells whether REQUIRETLS parameter has been given.
+	RequireTLS bool
+
+	// DsnReturn contains DSN return type declared with RET parameter (FULL or HDRS).
+	DsnReturn string
+
+	// DsnEnvelopeID contains DSN envelope identifier declared with ENVID parameter.
+	DsnEnvelopeID string
+
+	// MailAuth contains authorization identity declared with AUTH parameter of MAIL FROM command.
+	//
+	// nil means the parameter has not been given, while empty string stands for "AUTH=<>".
+	MailAuth *string
+}
+
+// Recipient structure represents individual envelope recipient.
+type Recipient struct {
+	// Address contains recipient address given in RCPT TO command.
+	Address string
+
+	// DsnNotify contains DSN notification conditions declared with NOTIFY parameter.
+	DsnNotify []string
+
+	// DsnOriginalRecipient contains original recipient address declared with ORCPT parameter.
+	DsnOriginalRecipient string
+
+	// DsnOriginalRecipientType contains address type of original recipient declared with ORCPT parameter.
+	DsnOriginalRecipientType string
+}
@@ -0,0 +1,89 @@
+package filter
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"zinktray/app/message"
+)
+
+// Filter describes conditions a message is to satisfy. Empty conditions are satisfied by any message.
+type Filter struct {
+	// Recipient contains address any of envelope recipients or To header addresses must contain. Case-insensitive.
+	Recipient string
+
+	// From contains address envelope return path or any of From header addresses must contain. Case-insensitive.
+	From string
+
+	// Subject contains regular expression decoded message subject must match.
+	Subject *regexp.Regexp
+
+	// After contains time message must be received at or after.
+	After time.Time
+}
+
+// IsEmpty tells whether filter is satisfied by any message.
+func (filter *Filter) IsEmpty() bool {
+	return filter.Recipient == "" && filter.From == "" && filter.Subject == nil && filter.After.IsZero()
+}
+
+// Match tests whether message satisfies filter conditions.
+//
+// Headers of messages which could not be parsed are considered empty.
+func (filter *Filter) Match(msg *message.Message) bool {
+	if !filter.After.IsZero() && msg.ReceivedAt.Before(filter.After) {
+		return false
+	}
+
+	if filter.Recipient == "" && filter.From == "" && filter.Subject == nil {
+		return true
+	}
+
+	info := msg.GetSummary()
+
+	if filter.Subject != nil && !filter.Subject.MatchString(info.Subject) {
+		return false
+	}
+
+	if filter.From != "" {
+		candidates := slices.Clone(info.From)
+
+		if msg.Envelope != nil {
+			candidates = append(candidates, msg.Envelope.ReturnPath)
+		}
+
+		if !containsFold(candidates, filter.From) {
+			return false
+		}
+	}
+
+	if filter.Recipient != "" {
+		candidates := slices.Clone(info.To)
+
+		if msg.Envelope != nil {
+			for _, rcpt := range msg.Envelope.Recipients {
+				candidates = append(candidates, rcpt.Address)
+			}
+		}
+
+		if !containsFold(candidates, filter.Recipient) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// containsFold tests whether any of candidates contains substr, ignoring case.
+func containsFold(candidates []string, substr string) bool {
+	substr = strings.ToLower(substr)
+
+	for _, candidate := range candidates {
+		if strings.Contains(strings.ToLower(candidate), substr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,45 @@
+package filter
+
+import (
+	"regexp"
+	"testing"
+	"time"
+	"zinktray/app/message"
+)
+
+func TestMatch(t *testing.T) {
+	var msg = message.NewMessage("Subject: Welcome to =?UTF-8?Q?Zink=E2=9C=93?=\r\nFrom: Robot <robot@example.com>\r\nTo: user@example.com\r\n\r\nBody")
+
+	msg.ReceivedAt = time.Unix(1000, 0)
+	msg.Envelope = &message.Envelope{
+		ReturnPath: "bounce@example.com",
+		Recipients: []message.Recipient{{Address: "hidden@example.com"}},
+	}
+
+	var cases = []struct {
+		name     string
+		filter   Filter
+		expected bool
+	}{
+		{"empty", Filter{}, true},
+		{"subject", Filter{Subject: regexp.MustCompile("^Welcome .*✓$")}, true},
+		{"subject mismatch", Filter{Subject: regexp.MustCompile("^Goodbye")}, false},
+		{"from header", Filter{From: "ROBOT@"}, true},
+		{"from envelope", Filter{From: "bounce@example.com"}, true},
+		{"from mismatch", Filter{From: "someone@example.com"}, false},
+		{"recipient header", Filter{Recipient: "user@example.com"}, true},
+		{"recipient envelope", Filter{Recipient: "hidden@example.com"}, true},
+		{"recipient mismatch", Filter{Recipient: "other@example.com"}, false},
+		{"after", Filter{After: time.Unix(1000, 0)}, true},
+		{"after mismatch", Filter{After: time.Unix(1001, 0)}, false},
+		{"combined", Filter{From: "robot", Recipient: "user", Subject: regexp.MustCompile("Welcome")}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if result := c.filter.Match(msg); result != c.expected {
+				t.Fatalf("Match result does not match: got %t, expected %t", result, c.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package message
+
+// Info describes essential information on individual message as exposed to clients, both through HTTP API and
+// webhook notifications.
+type Info struct {
+	ID             string         `json:"id"`
+	From           []string       `json:"from"`
+	To             []string       `json:"to"`
+	Subject        string         `json:"subject"`
+	RawHeaders     RawHeadersInfo `json:"rawHeaders"`
+	ReceivedAt     int64          `json:"receivedAt"`
+	Envelope       *EnvelopeInfo  `json:"envelope"`
+	Size           int            `json:"size"`
+	HasAttachments bool           `json:"hasAttachments"`
+	Snippet        string         `json:"snippet"`
+	Error          *string        `json:"error"`
+}
+
+// RawHeadersInfo describes raw values of message headers exposed in decoded form.
+type RawHeadersInfo struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+}
+
+// EnvelopeInfo describes SMTP envelope of a message as exposed to clients.
+type EnvelopeInfo struct {
+	ReturnPath    string          `json:"returnPath"`
+	Recipients    []RecipientInfo `json:"recipients"`
+	Helo          string          `json:"helo"`
+	ClientIP      string          `json:"clientIp"`
+	TLS           bool            `json:"tls"`
+	AuthUsername  string          `json:"authUsername"`
+	Size          int64           `json:"size"`
+	Body          string          `json:"body"`
+	SmtpUtf8      bool            `json:"smtpUtf8"`
+	RequireTLS    bool            `json:"requireTls"`
+	DsnReturn     string          `json:"dsnReturn"`
+	DsnEnvelopeID string          `json:"dsnEnvelopeId"`
+	MailAuth      *string         `json:"mailAuth"`
+}
+
+// RecipientInfo describes individual envelope recipient as exposed to clients.
+type RecipientInfo struct {
+	Address                  string   `json:"address"`
+	DsnNotify                []string `json:"dsnNotify"`
+	DsnOriginalRecipient     string   `json:"dsnOriginalRecipient"`
+	DsnOriginalRecipientType string   `json:"dsnOriginalRecipientType"`
+}
+
+// NewInfo extracts essential information out of message summary.
+//
+// Message which could not be parsed is described as far as possible, along with the error.
+func NewInfo(msg *Message) Info {
+	summary := msg.GetSummary()
+
+	info := Info{
+		ID:             msg.ID,
+		From:           summary.From,
+		To:             summary.To,
+		Subject:        summary.Subject,
+		RawHeaders:     NewRawHeadersInfo(summary),
+		ReceivedAt:     msg.ReceivedAt.Unix(),
+		Envelope:       NewEnvelopeInfo(msg.Envelope),
+		Size:           summary.Size,
+		HasAttachments: summary.HasAttachments,
+		Snippet:        summary.Snippet,
+	}
+
+	if summary.Error != "" {
+		parseError := summary.Error
+		info.Error = &parseError
+	}
+
+	return info
+}
+
+// NewRawHeadersInfo extracts raw header values out of message summary.
+func NewRawHeadersInfo(summary *Summary) RawHeadersInfo {
+	return RawHeadersInfo{
+		From:    summary.RawFrom,
+		To:      summary.RawTo,
+		Subject: summary.RawSubject,
+	}
+}
+
+// NewEnvelopeInfo converts message envelope into its client representation.
+//
+// Returns nil for nil envelope.
+func NewEnvelopeInfo(envelope *Envelope) *EnvelopeInfo {
+	if envelope == nil {
+		return nil
+	}
+
+	recipients := make([]RecipientInfo, 0, len(envelope.Recipients))
+
+	for _, rcpt := range envelope.Recipients {
+		notify := rcpt.DsnNotify
+
+		if notify == nil {
+			notify = make([]string, 0)
+		}
+
+		recipients = append(recipients, RecipientInfo{
+			Address:                  rcpt.Address,
+			DsnNotify:                notify,
+			DsnOriginalRecipient:     rcpt.DsnOriginalRecipient,
+			DsnOriginalRecipientType: rcpt.DsnOriginalRecipientType,
+		})
+	}
+
+	return &EnvelopeInfo{
+		ReturnPath:    envelope.ReturnPath,
+		Recipients:    recipients,
+		Helo:          envelope.Helo,
+		ClientIP:      envelope.ClientIP,
+		TLS:           envelope.TLS,
+		AuthUsername:  envelope.AuthUsername,
+		Size:          envelope.Size,
+		Body:          envelope.Body,
+		SmtpUtf8:      envelope.SmtpUtf8,
+		RequireTLS:    envelope.RequireTLS,
+		DsnReturn:     envelope.DsnReturn,
+		DsnEnvelopeID: envelope.DsnEnvelopeID,
+		MailAuth:      envelope.MailAuth,
+	}
+}
@@ -17,15 +17,29 @@ type Message struct {
 	// ReceivedAt contains time message has been received at.
 	ReceivedAt time.Time
 
+	// Envelope contains SMTP envelope the message has been delivered with.
+	//
+	// nil means the message has not been delivered via SMTP.
+	Envelope *Envelope
+
 	// rawData contains raw message contents along with body and headers.
 	//
 	// Contents of rawData is compressed. Use GetRawData to read and SetRawData to write
 	// uncompressed message contents.
 	rawData string
+
+	// summary contains information extracted out of raw message contents upon writing them.
+	summary *Summary
 }
 
 // GetRawData reads uncompressed raw message contents.
+//
+// Returns empty string when raw message contents have never been written.
 func (msg *Message) GetRawData() string {
+	if msg.rawData == "" {
+		return ""
+	}
+
 	rd, err := gzip.NewReader(strings.NewReader(msg.rawData))
 
 	if err != nil {
@@ -43,7 +57,23 @@ func (msg *Message) GetRawData() string {
 	return string(rawData)
 }
 
-// SetRawData writes uncompressed raw message contents.
+// GetCompressedSize returns size of raw message contents as kept compressed, in bytes.
+func (msg *Message) GetCompressedSize() int {
+	return len(msg.rawData)
+}
+
+// GetSummary returns information extracted out of raw message contents.
+//
+// Summary is extracted once raw message contents are written, and must not be modified.
+func (msg *Message) GetSummary() *Summary {
+	if msg.summary == nil {
+		return newSummary("")
+	}
+
+	return msg.summary
+}
+
+// SetRawData writes uncompressed raw message contents and extracts message summary out of them.
 func (msg *Message) SetRawData(rawData string) {
 	var out bytes.Buffer
 
@@ -58,6 +88,7 @@ func (msg *Message) SetRawData(rawData string) {
 	writer.Close()
 
 	msg.rawData = out.String()
+	msg.summary = newSummary(rawData)
 }
 
 // NewMessage creates new message structure.
@@ -4,7 +4,11 @@ package parse
 //
 // Intended for message introspection without delving into depths of its body (probably deep and complex).
 type BasicInfo struct {
-	From    []string
-	To      []string
-	Subject string
+	From    []string // Sender addresses with display names decoded.
+	To      []string // Recipient addresses with display names decoded.
+	Subject string   // Subject with encoded-words decoded.
+
+	RawFrom    string // Raw From header value.
+	RawTo      string // Raw To header value.
+	RawSubject string // Raw Subject header value.
 }
@@ -2,10 +2,25 @@ package parse
 
 // ContentInfo contains information required to render message contents.
 //
-// todo: Implement attachments.
 // todo: Implement proxying embedded images.
 type ContentInfo struct {
-	Html  *string // HTML message contents. nil means message has no HTML contents.
-	Plain *string // Plain text message contents. nil means message has no plain-text contents.
-	Raw   string  // Raw message body. Headers included.
+	Html         *string       // HTML message contents. nil means message has no HTML contents.
+	HtmlCharset  string        // Original charset of HTML contents. Empty when message has no HTML contents.
+	Plain        *string       // Plain text message contents. nil means message has no plain-text contents.
+	PlainCharset string        // Original charset of plain text contents. Empty when message has no plain-text contents.
+	Raw          string        // Raw message body. Headers included.
+	Attachments  []*Attachment // Message attachments in order of appearance.
+}
+
+// Attachment contains information on individual message attachment.
+//
+// Every non-multipart part which is either not human-readable or explicitly marked with "attachment" disposition is
+// treated as attachment. This includes inline images referenced from HTML contents.
+type Attachment struct {
+	Index       int    // Zero-based attachment index in order of appearance.
+	Filename    string // Attachment file name. Empty when not given.
+	ContentType string // Attachment media type normalized to lowercase.
+	ContentID   string // Attachment content ID without angle brackets. Empty when not given.
+	Disposition string // Attachment disposition ("attachment" or "inline") normalized to lowercase. Empty when not given.
+	Data        []byte // Attachment contents with transfer encoding decoded.
 }
@@ -0,0 +1,93 @@
+package parse
+
+import (
+	"encoding/base64"
+	"io"
+	"log"
+	"mime/quotedprintable"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// defaultCharset contains charset of text parts not declaring one.
+const defaultCharset = "us-ascii"
+
+// readText reads contents of human-readable part, decoding transfer encoding and converting text to UTF-8.
+//
+// Returns contents along with original charset normalized to lowercase. Contents in unknown charset are returned
+// verbatim.
+func readText(part Part, charset string) (string, string, error) {
+	content, err := io.ReadAll(decodeTransferEncoding(part.GetReader(), part.GetHeader("Content-Transfer-Encoding")))
+
+	if err != nil {
+		return "", "", err
+	}
+
+	charset = strings.ToLower(strings.TrimSpace(charset))
+
+	if charset == "" {
+		charset = defaultCharset
+	}
+
+	decoded, err := decodeCharset(content, charset)
+
+	if err != nil {
+		log.Printf("Cannot convert text from charset \"%s\": %s\n", charset, err)
+
+		return string(content), charset, nil
+	}
+
+	return decoded, charset, nil
+}
+
+// decodeTransferEncoding wraps reader to decode contents according to Content-Transfer-Encoding header value.
+//
+// Identity encodings (7bit, 8bit, binary) and unknown encodings are read verbatim.
+func decodeTransferEncoding(reader io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, reader)
+	case "quoted-printable":
+		return quotedprintable.NewReader(reader)
+	}
+
+	return reader
+}
+
+// charsetReader wraps input to convert text from charset to UTF-8.
+//
+// Suits mime.WordDecoder CharsetReader field.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	encoding, err := htmlindex.Get(strings.ToLower(charset))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+// decodeCharset converts text from charset to UTF-8.
+//
+// charset is expected to be normalized to lowercase.
+func decodeCharset(content []byte, charset string) (string, error) {
+	switch charset {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return string(content), nil
+	}
+
+	encoding, err := htmlindex.Get(charset)
+
+	if err != nil {
+		return "", err
+	}
+
+	decoded, err := encoding.NewDecoder().Bytes(content)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(decoded), nil
+}
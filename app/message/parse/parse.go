@@ -10,6 +10,16 @@ import (
 	"strings"
 )
 
+// wordDecoder decodes RFC 2047 encoded-words written in any charset known to decodeCharset.
+var wordDecoder = &mime.WordDecoder{
+	CharsetReader: charsetReader,
+}
+
+// addressParser parses address lists decoding display names with wordDecoder.
+var addressParser = &mail.AddressParser{
+	WordDecoder: wordDecoder,
+}
+
 // ReadBasic extracts basic message information from its raw body.
 func ReadBasic(body string) (*BasicInfo, error) {
 	msg, err := parseMessage(body)
@@ -19,9 +29,12 @@ func ReadBasic(body string) (*BasicInfo, error) {
 	}
 
 	return &BasicInfo{
-		Subject: msg.Header.Get("Subject"),
-		From:    extractAddressList(msg, "From"),
-		To:      extractAddressList(msg, "To"),
+		Subject:    decodeHeader(msg.Header.Get("Subject")),
+		From:       extractAddressList(msg, "From"),
+		To:         extractAddressList(msg, "To"),
+		RawSubject: msg.Header.Get("Subject"),
+		RawFrom:    msg.Header.Get("From"),
+		RawTo:      msg.Header.Get("To"),
 	}, nil
 }
 
@@ -33,22 +46,20 @@ func ReadContents(body string) (*ContentInfo, error) {
 		return nil, err
 	}
 
-	contentPlain, contentHtml, err := readStructure(msg)
+	contents, err := readStructure(msg)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if contentHtml == nil && contentPlain == nil {
+	if contents.Html == nil && contents.Plain == nil {
 		// Message must always have readable content, even if it is empty
-		contentPlain = new(string)
+		contents.Plain = new(string)
 	}
 
-	return &ContentInfo{
-		Html:  contentHtml,
-		Plain: contentPlain,
-		Raw:   body,
-	}, nil
+	contents.Raw = body
+
+	return contents, nil
 }
 
 // parseMessage parses raw message body into readable structure.
@@ -60,8 +71,10 @@ func parseMessage(body string) (*mail.Message, error) {
 	}
 }
 
-func parsePart(part Part) (*string, *string, error) {
-	var contentPlain, contentHtml *string
+func parsePart(part Part) (*ContentInfo, error) {
+	contents := &ContentInfo{
+		Attachments: make([]*Attachment, 0),
+	}
 
 	partIndexStack := make([]uint, 0, 3)
 	readersStack := make([]*multipart.Reader, 0, 3)
@@ -74,10 +87,10 @@ func parsePart(part Part) (*string, *string, error) {
 ReadParts:
 	for {
 		if currentPart != nil {
-			mediaType, boundary, err := extractMediaType(currentPart.GetHeader("Content-Type"))
+			mediaType, params, err := extractMediaType(currentPart.GetHeader("Content-Type"))
 
 			if err != nil {
-				return nil, nil, fmt.Errorf(
+				return nil, fmt.Errorf(
 					"cannot extract media type out of part %d, level %d: %w",
 					partIndex,
 					level,
@@ -85,8 +98,8 @@ ReadParts:
 				)
 			}
 
-			if isMultipart(mediaType) && boundary != "" {
-				nextReader := multipart.NewReader(currentPart.GetReader(), boundary)
+			if isMultipart(mediaType) && params["boundary"] != "" {
+				nextReader := multipart.NewReader(currentPart.GetReader(), params["boundary"])
 
 				if currentReader != nil {
 					readersStack = append(readersStack, currentReader)
@@ -96,21 +109,39 @@ ReadParts:
 				currentReader = nextReader
 				level++
 				partIndex = 0
-			} else if isHumanReadable(mediaType) {
-				if content, err := io.ReadAll(currentPart.GetReader()); err != nil {
-					return nil, nil, fmt.Errorf(
+			} else if isAttachment(currentPart, mediaType) {
+				if attachment, err := readAttachment(currentPart, mediaType, params); err != nil {
+					return nil, fmt.Errorf(
+						"cannot read attachment of part %d, level %d: %w",
+						partIndex,
+						level,
+						err,
+					)
+				} else {
+					attachment.Index = len(contents.Attachments)
+					contents.Attachments = append(contents.Attachments, attachment)
+				}
+			} else {
+				if content, charset, err := readText(currentPart, params["charset"]); err != nil {
+					return nil, fmt.Errorf(
 						"cannot read content of part %d, level %d: %w",
 						partIndex,
 						level,
 						err,
 					)
 				} else if isHtml(mediaType) {
-					contentHtml = appendString(contentHtml, string(content))
+					contents.Html = appendString(contents.Html, content)
+
+					if contents.HtmlCharset == "" {
+						contents.HtmlCharset = charset
+					}
 				} else {
-					contentPlain = appendString(contentPlain, string(content))
+					contents.Plain = appendString(contents.Plain, content)
+
+					if contents.PlainCharset == "" {
+						contents.PlainCharset = charset
+					}
 				}
-			} else {
-				// todo: Probably we can treat this part as attachment
 			}
 		}
 
@@ -129,7 +160,7 @@ ReadParts:
 
 					level--
 				} else if err != nil {
-					return nil, nil, fmt.Errorf(
+					return nil, fmt.Errorf(
 						"cannot read multipart contents of part %d, level %d: %w",
 						partIndex,
 						level,
@@ -148,12 +179,12 @@ ReadParts:
 		}
 	}
 
-	return contentPlain, contentHtml, nil
+	return contents, nil
 }
 
 // readStructure reads message structure and returns aggregated contents. These include readable plain-text and HTML
-// content.
-func readStructure(msg *mail.Message) (*string, *string, error) {
+// content, and attachments.
+func readStructure(msg *mail.Message) (*ContentInfo, error) {
 	part := &MessagePart{
 		msg: msg,
 	}
@@ -161,6 +192,21 @@ func readStructure(msg *mail.Message) (*string, *string, error) {
 	return parsePart(part)
 }
 
+// decodeHeader decodes RFC 2047 encoded-words in header value.
+//
+// Returns header value verbatim when it cannot be decoded.
+func decodeHeader(header string) string {
+	decoded, err := wordDecoder.DecodeHeader(header)
+
+	if err != nil {
+		log.Printf("Cannot decode header: %s. Raw header: %s\n", err, header)
+
+		return header
+	}
+
+	return decoded
+}
+
 // appendString appends an appendWhat string to a string pointed to by appendTo.
 func appendString(appendTo *string, appendWhat string) *string {
 	if appendTo == nil {
@@ -172,9 +218,57 @@ func appendString(appendTo *string, appendWhat string) *string {
 	return appendTo
 }
 
+// readAttachment reads attachment contents and metadata out of message part.
+func readAttachment(part Part, mediaType string, mediaParams map[string]string) (*Attachment, error) {
+	data, err := io.ReadAll(decodeTransferEncoding(part.GetReader(), part.GetHeader("Content-Transfer-Encoding")))
+
+	if err != nil {
+		return nil, err
+	}
+
+	disposition, dispositionParams := extractDisposition(part)
+	filename := dispositionParams["filename"]
+
+	if filename == "" {
+		filename = mediaParams["name"]
+	}
+
+	filename = decodeHeader(filename)
+
+	return &Attachment{
+		Filename:    filename,
+		ContentType: mediaType,
+		ContentID:   strings.Trim(strings.TrimSpace(part.GetHeader("Content-ID")), "<>"),
+		Disposition: disposition,
+		Data:        data,
+	}, nil
+}
+
+// extractDisposition retrieves disposition type and parameters out of part Content-Disposition header.
+//
+// Returned disposition type is normalized to lowercase. Returns empty disposition type when header is missing or
+// malformed.
+func extractDisposition(part Part) (string, map[string]string) {
+	disposition, params, err := mime.ParseMediaType(part.GetHeader("Content-Disposition"))
+
+	if err != nil {
+		return "", map[string]string{}
+	}
+
+	return strings.ToLower(disposition), params
+}
+
 // Extracts addresses from message header.
+//
+// Display names are decoded from RFC 2047 encoded-words.
 func extractAddressList(message *mail.Message, headerKey string) []string {
-	addressList, err := message.Header.AddressList(headerKey)
+	header := message.Header.Get(headerKey)
+
+	if header == "" {
+		return make([]string, 0)
+	}
+
+	addressList, err := addressParser.ParseList(header)
 
 	if err != nil {
 		log.Printf(
@@ -202,17 +296,34 @@ func extractAddressList(message *mail.Message, headerKey string) []string {
 	return result
 }
 
-// extractMediaType retrieves media type and value of boundary parameter from Content-Type header value.
+// extractMediaType retrieves media type and its parameters from Content-Type header value.
 //
-// Returned media type is normalized to lowercase.
-func extractMediaType(contentType string) (string, string, error) {
+// Missing header stands for US-ASCII plain text. Returned media type is normalized to lowercase.
+func extractMediaType(contentType string) (string, map[string]string, error) {
+	if strings.TrimSpace(contentType) == "" {
+		return "text/plain", map[string]string{"charset": defaultCharset}, nil
+	}
+
 	mediaType, params, err := mime.ParseMediaType(contentType)
 
 	if err != nil {
-		return "", "", fmt.Errorf("cannot extract boundary: %w", err)
+		return "", nil, fmt.Errorf("cannot extract media type: %w", err)
 	}
 
-	return strings.ToLower(mediaType), params["boundary"], nil
+	return strings.ToLower(mediaType), params, nil
+}
+
+// isAttachment tests whether non-multipart part is to be treated as attachment rather than readable content.
+//
+// mediaType is expected to be normalized to lowercase.
+func isAttachment(part Part, mediaType string) bool {
+	if !isHumanReadable(mediaType) {
+		return true
+	}
+
+	disposition, _ := extractDisposition(part)
+
+	return disposition == "attachment"
 }
 
 // isHumanReadable tests whether media could be read by humans.
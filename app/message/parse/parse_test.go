@@ -0,0 +1,136 @@
+package parse
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadContentsAttachments(t *testing.T) {
+	var contents = readContents(t, "testdata/attachments.txt")
+
+	if contents.Html == nil || *contents.Html != `<img src="cid:logo@example.com">` {
+		t.Errorf("Unexpected HTML contents: %v", contents.Html)
+	}
+
+	if contents.Plain != nil {
+		t.Errorf("Attachment is not expected to be treated as plain text contents: %s", *contents.Plain)
+	}
+
+	var attachmentsExpected = []Attachment{
+		{Index: 0, ContentType: "image/png", ContentID: "logo@example.com", Disposition: "inline", Data: []byte("\x89PNG\r\n\x1a\n")},
+		{Index: 1, Filename: "invoice.pdf", ContentType: "application/pdf", Disposition: "attachment", Data: []byte("%PDF-1.4\n%%EOF\n")},
+		{Index: 2, Filename: "счет.txt", ContentType: "text/plain", Disposition: "attachment", Data: []byte("Total: 42")},
+	}
+
+	if len(contents.Attachments) != len(attachmentsExpected) {
+		t.Fatalf("Attachment count does not match: got %d, expected %d", len(contents.Attachments), len(attachmentsExpected))
+	}
+
+	for i, expected := range attachmentsExpected {
+		var attachment = contents.Attachments[i]
+
+		if attachment.Index != expected.Index ||
+			attachment.Filename != expected.Filename ||
+			attachment.ContentType != expected.ContentType ||
+			attachment.ContentID != expected.ContentID ||
+			attachment.Disposition != expected.Disposition ||
+			string(attachment.Data) != string(expected.Data) {
+			t.Errorf("Attachment does not match at index %d: got %+v, expected %+v", i, *attachment, expected)
+		}
+	}
+}
+
+func readContents(t *testing.T, path string) *ContentInfo {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		t.Fatalf("Cannot read file \"%s\": %s", path, err)
+	}
+
+	contents, err := ReadContents(string(data))
+
+	if err != nil {
+		t.Fatalf("Cannot read message contents: %s", err)
+	}
+
+	return contents
+}
+
+func TestReadContentsEncodings(t *testing.T) {
+	var cases = []struct {
+		src          string
+		plain        string
+		plainCharset string
+		html         string
+		htmlCharset  string
+	}{
+		{"testdata/base64-utf8.txt", "Привет, мир!", "utf-8", "", ""},
+		{"testdata/quoted-printable-koi8r.txt", "Привет, мир!\r\n", "koi8-r", "", ""},
+		{"testdata/charsets.txt", "Здравствуйте こんにちは", "windows-1251", "<p>Café déjà vu</p>", "iso-8859-1"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.src, func(t *testing.T) {
+			var contents = readContents(t, c.src)
+
+			if contents.Plain == nil || *contents.Plain != c.plain {
+				t.Errorf("Plain text contents do not match: got %q, expected %q", derefString(contents.Plain), c.plain)
+			}
+
+			if contents.PlainCharset != c.plainCharset {
+				t.Errorf("Plain text charset does not match: got \"%s\", expected \"%s\"", contents.PlainCharset, c.plainCharset)
+			}
+
+			if derefString(contents.Html) != c.html {
+				t.Errorf("HTML contents do not match: got %q, expected %q", derefString(contents.Html), c.html)
+			}
+
+			if contents.HtmlCharset != c.htmlCharset {
+				t.Errorf("HTML charset does not match: got \"%s\", expected \"%s\"", contents.HtmlCharset, c.htmlCharset)
+			}
+		})
+	}
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+func TestReadBasicEncodedWords(t *testing.T) {
+	data, err := os.ReadFile("testdata/encoded-words.txt")
+
+	if err != nil {
+		t.Fatalf("Cannot read file: %s", err)
+	}
+
+	info, err := ReadBasic(string(data))
+
+	if err != nil {
+		t.Fatalf("Cannot read basic message info: %s", err)
+	}
+
+	if info.Subject != "Добро пожаловать✓" {
+		t.Errorf("Subject does not match: got \"%s\", expected \"%s\"", info.Subject, "Добро пожаловать✓")
+	}
+
+	if !strings.HasPrefix(info.RawSubject, "=?windows-1251?B?") {
+		t.Errorf("Raw subject is expected to be encoded, got \"%s\"", info.RawSubject)
+	}
+
+	var fromExpected = []string{"Иван Петров <ivan@example.com>"}
+	var toExpected = []string{"山田 <yamada@example.jp>", "<plain@example.com>"}
+
+	if !slices.Equal(info.From, fromExpected) {
+		t.Errorf("From does not match: got %q, expected %q", info.From, fromExpected)
+	}
+
+	if !slices.Equal(info.To, toExpected) {
+		t.Errorf("To does not match: got %q, expected %q", info.To, toExpected)
+	}
+}
@@ -0,0 +1,129 @@
+package message
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+	"zinktray/app/message/parse"
+)
+
+// snippetLength contains maximum length of message snippet, in characters.
+const snippetLength = 160
+
+// htmlTagPattern matches HTML tags, which are not included in snippets.
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// Summary structure contains message information extracted once message is received.
+//
+// Intended for listing messages without parsing every one of them over and over.
+type Summary struct {
+	// From contains sender addresses with display names decoded.
+	From []string
+
+	// To contains recipient addresses with display names decoded.
+	To []string
+
+	// Subject contains subject with encoded-words decoded.
+	Subject string
+
+	// RawFrom contains raw From header value.
+	RawFrom string
+
+	// RawTo contains raw To header value.
+	RawTo string
+
+	// RawSubject contains raw Subject header value.
+	RawSubject string
+
+	// Size contains size of raw message contents, in bytes.
+	Size int
+
+	// HasAttachments tells whether message has attachments.
+	HasAttachments bool
+
+	// Snippet contains beginning of readable message content with whitespace collapsed.
+	Snippet string
+
+	// Text contains readable message content, plain text followed by HTML with markup stripped.
+	//
+	// Intended for indexing message content without parsing it again.
+	Text []string
+
+	// AttachmentNames contains file names of message attachments.
+	AttachmentNames []string
+
+	// Error contains description of the error message could not be parsed due to. Empty for valid messages.
+	//
+	// Summary of invalid message may be incomplete.
+	Error string
+}
+
+// newSummary extracts summary out of raw message contents.
+func newSummary(rawData string) *Summary {
+	summary := &Summary{
+		From: make([]string, 0),
+		To:   make([]string, 0),
+		Size: len(rawData),
+	}
+
+	info, err := parse.ReadBasic(rawData)
+
+	if err != nil {
+		summary.Error = fmt.Sprintf("cannot extract basic message info: %s", err)
+
+		return summary
+	}
+
+	summary.From = info.From
+	summary.To = info.To
+	summary.Subject = info.Subject
+	summary.RawFrom = info.RawFrom
+	summary.RawTo = info.RawTo
+	summary.RawSubject = info.RawSubject
+
+	contents, err := parse.ReadContents(rawData)
+
+	if err != nil {
+		summary.Error = fmt.Sprintf("cannot extract message content: %s", err)
+
+		return summary
+	}
+
+	summary.HasAttachments = len(contents.Attachments) > 0
+
+	for _, attachment := range contents.Attachments {
+		summary.AttachmentNames = append(summary.AttachmentNames, attachment.Filename)
+	}
+
+	var htmlText string
+
+	if contents.Plain != nil {
+		summary.Text = append(summary.Text, *contents.Plain)
+	}
+
+	if contents.Html != nil {
+		htmlText = html.UnescapeString(htmlTagPattern.ReplaceAllString(*contents.Html, " "))
+		summary.Text = append(summary.Text, htmlText)
+	}
+
+	if contents.Plain != nil && strings.TrimSpace(*contents.Plain) != "" {
+		summary.Snippet = newSnippet(*contents.Plain)
+	} else if contents.Html != nil {
+		summary.Snippet = newSnippet(htmlText)
+	}
+
+	return summary
+}
+
+// newSnippet collapses whitespace in text and truncates it to snippetLength characters.
+func newSnippet(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+
+	if utf8.RuneCountInString(text) <= snippetLength {
+		return text
+	}
+
+	return string([]rune(text)[:snippetLength-1]) + "…"
+}
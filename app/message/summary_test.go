@@ -0,0 +1,90 @@
+package message
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	var raw = "From: =?UTF-8?Q?Jos=C3=A9?= <jose@example.com>\r\n" +
+		"To: user@example.com\r\n" +
+		"Subject: Report\r\n" +
+		"Content-Type: multipart/mixed; boundary=B\r\n" +
+		"\r\n" +
+		"--B\r\n" +
+		"Content-Type: text/html\r\n" +
+		"\r\n" +
+		"<p>Numbers&nbsp;are</p>\r\n<p><b>up</b></p>\r\n" +
+		"--B\r\n" +
+		"Content-Type: application/pdf\r\n" +
+		"\r\n" +
+		"%PDF\r\n" +
+		"--B--\r\n"
+
+	var summary = NewMessage(raw).GetSummary()
+
+	if summary.Error != "" {
+		t.Fatalf("Unexpected error: %s", summary.Error)
+	}
+
+	if !slices.Equal(summary.From, []string{"José <jose@example.com>"}) {
+		t.Errorf("From does not match: got %v", summary.From)
+	}
+
+	if !slices.Equal(summary.To, []string{"<user@example.com>"}) {
+		t.Errorf("To does not match: got %v", summary.To)
+	}
+
+	if summary.Subject != "Report" || summary.RawFrom != "=?UTF-8?Q?Jos=C3=A9?= <jose@example.com>" {
+		t.Errorf("Headers do not match: got %q, %q", summary.Subject, summary.RawFrom)
+	}
+
+	if summary.Size != len(raw) {
+		t.Errorf("Size does not match: got %d, expected %d", summary.Size, len(raw))
+	}
+
+	if !summary.HasAttachments {
+		t.Error("Message is expected to have attachments")
+	}
+
+	if expected := "Numbers are up"; summary.Snippet != expected {
+		t.Errorf("Snippet does not match: got %q, expected %q", summary.Snippet, expected)
+	}
+
+	if len(summary.Text) != 1 || !strings.Contains(summary.Text[0], "Numbers\u00a0are") || strings.Contains(summary.Text[0], "<b>") {
+		t.Errorf("Text does not match: got %q", summary.Text)
+	}
+
+	if len(summary.AttachmentNames) != 1 {
+		t.Errorf("Attachment names do not match: got %q", summary.AttachmentNames)
+	}
+}
+
+func TestSummaryLongSnippet(t *testing.T) {
+	var summary = NewMessage("Subject: Long\r\n\r\n" + strings.Repeat("ж ", 200)).GetSummary()
+
+	if length := len([]rune(summary.Snippet)); length != snippetLength {
+		t.Fatalf("Snippet length does not match: got %d, expected %d", length, snippetLength)
+	}
+
+	if !strings.HasSuffix(summary.Snippet, "…") {
+		t.Fatalf("Truncated snippet is expected to end with ellipsis: got %q", summary.Snippet)
+	}
+}
+
+func TestSummaryInvalid(t *testing.T) {
+	var summary = NewMessage("Not a message").GetSummary()
+
+	if summary.Error == "" {
+		t.Fatal("Error is expected for invalid message")
+	}
+
+	if summary.From == nil || summary.To == nil {
+		t.Fatal("Address lists are expected to be empty rather than nil")
+	}
+
+	if summary = (&Message{}).GetSummary(); summary == nil {
+		t.Fatal("Summary is expected for message without raw data")
+	}
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// labelValueReplacer escapes label values according to Prometheus text format.
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// counter represents monotonically increasing value partitioned by label values.
+type counter struct {
+	mutex sync.Mutex
+
+	// name contains metric name.
+	name string
+
+	// help contains metric description.
+	help string
+
+	// labels contains names of labels the counter is partitioned by.
+	labels []string
+
+	// series maps label values joined with zero byte to the value of respective series.
+	series map[string]float64
+}
+
+// add increments the series identified by label values, which go in the same order as label names.
+func (c *counter) add(value float64, labelValues ...string) {
+	c.mutex.Lock()
+
+	defer c.mutex.Unlock()
+
+	c.series[strings.Join(labelValues, "\x00")] += value
+}
+
+// write writes counter in Prometheus text format, series ordered by label values.
+func (c *counter) write(output io.Writer) {
+	c.mutex.Lock()
+
+	defer c.mutex.Unlock()
+
+	writeHeader(output, c.name, c.help, "counter")
+
+	// Counter without labels is exposed before anything is counted.
+	if len(c.labels) == 0 && len(c.series) == 0 {
+		fmt.Fprintf(output, "%s 0\n", c.name)
+
+		return
+	}
+
+	keys := make([]string, 0, len(c.series))
+
+	for key := range c.series {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(output, "%s%s %s\n", c.name, formatLabels(c.labels, strings.Split(key, "\x00")), formatValue(c.series[key]))
+	}
+}
+
+// writeHeader writes HELP and TYPE lines of a metric.
+func writeHeader(output io.Writer, name string, help string, metricType string) {
+	fmt.Fprintf(output, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(output, "# TYPE %s %s\n", name, metricType)
+}
+
+// formatLabels formats label set, e.g. `{route="/api/messages",status="200"}`. Returns empty string for no labels.
+func formatLabels(names []string, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(names))
+
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+labelValueReplacer.Replace(values[i])+`"`)
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatValue formats sample value.
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// newCounter creates new counter structure.
+func newCounter(name string, help string, labels ...string) *counter {
+	return &counter{
+		name:   name,
+		help:   help,
+		labels: labels,
+		series: make(map[string]float64),
+	}
+}
@@ -0,0 +1,174 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"zinktray/app/storage"
+)
+
+// ContentType contains media type of Prometheus text format metrics are written in.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metrics collects counters of mail flowing through the server.
+//
+// Safe for concurrent use.
+type Metrics struct {
+	smtpConnections  *counter
+	smtpAuth         *counter
+	messagesAccepted *counter
+	messagesRejected *counter
+	rcptsRejected    *counter
+	receivedBytes    *counter
+	apiRequests      *counter
+}
+
+// SmtpConnection counts accepted SMTP connection.
+func (metrics *Metrics) SmtpConnection() {
+	metrics.smtpConnections.add(1)
+}
+
+// SmtpAuth counts SMTP authentication attempt.
+func (metrics *Metrics) SmtpAuth(success bool) {
+	if success {
+		metrics.smtpAuth.add(1, "success")
+	} else {
+		metrics.smtpAuth.add(1, "failure")
+	}
+}
+
+// MessageAccepted counts message accepted over SMTP along with its size.
+func (metrics *Metrics) MessageAccepted(size int) {
+	metrics.messagesAccepted.add(1)
+	metrics.receivedBytes.add(float64(size))
+}
+
+// MessageRejected counts message rejected over SMTP for provided reason.
+func (metrics *Metrics) MessageRejected(reason string) {
+	metrics.messagesRejected.add(1, reason)
+}
+
+// RecipientRejected counts SMTP recipient rejected for provided reason.
+//
+// Counted apart from messages, since a single message may have several recipients rejected and still be accepted.
+func (metrics *Metrics) RecipientRejected(reason string) {
+	metrics.rcptsRejected.add(1, reason)
+}
+
+// Instrument wraps HTTP handler, so that its requests are counted by route and response status.
+func (metrics *Metrics) Instrument(route string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: response, status: http.StatusOK}
+
+		handler.ServeHTTP(recorder, request)
+
+		metrics.apiRequests.add(1, route, strconv.Itoa(recorder.status))
+	})
+}
+
+// Write writes counters, followed by storage totals, in Prometheus text format.
+func (metrics *Metrics) Write(output io.Writer, stats storage.Stats) {
+	for _, c := range []*counter{
+		metrics.smtpConnections,
+		metrics.smtpAuth,
+		metrics.messagesAccepted,
+		metrics.messagesRejected,
+		metrics.rcptsRejected,
+		metrics.receivedBytes,
+		metrics.apiRequests,
+	} {
+		c.write(output)
+	}
+
+	for _, gauge := range []struct {
+		name  string
+		help  string
+		value int64
+	}{
+		{"zinktray_mailboxes", "Number of registered mailboxes.", int64(stats.Mailboxes)},
+		{"zinktray_messages", "Number of stored messages.", int64(stats.Messages)},
+		{"zinktray_stored_bytes", "Total size of stored messages, in bytes.", stats.Size},
+		{"zinktray_stored_compressed_bytes", "Total size of stored messages as kept compressed, in bytes.", stats.CompressedSize},
+	} {
+		writeHeader(output, gauge.name, gauge.help, "gauge")
+		fmt.Fprintf(output, "%s %d\n", gauge.name, gauge.value)
+	}
+}
+
+// statusRecorder captures status of HTTP response.
+type statusRecorder struct {
+	http.ResponseWriter
+
+	// status contains response status, HTTP 200 OK unless written explicitly.
+	status int
+
+	// wroteHeader tells whether response header has been written.
+	wroteHeader bool
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if !recorder.wroteHeader {
+		recorder.status = status
+		recorder.wroteHeader = true
+	}
+
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *statusRecorder) Write(data []byte) (int, error) {
+	recorder.wroteHeader = true
+
+	return recorder.ResponseWriter.Write(data)
+}
+
+// Flush sends buffered data to the client, so that event streams keep working when instrumented.
+func (recorder *statusRecorder) Flush() {
+	if flusher, ok := recorder.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns underlying response writer for http.ResponseController.
+func (recorder *statusRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
+
+// NewMetrics creates new metrics structure with every counter at zero.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		smtpConnections: newCounter(
+			"zinktray_smtp_connections_total",
+			"Number of accepted SMTP connections.",
+		),
+		smtpAuth: newCounter(
+			"zinktray_smtp_auth_total",
+			"Number of SMTP authentication attempts by result.",
+			"result",
+		),
+		messagesAccepted: newCounter(
+			"zinktray_smtp_messages_accepted_total",
+			"Number of messages accepted over SMTP.",
+		),
+		messagesRejected: newCounter(
+			"zinktray_smtp_messages_rejected_total",
+			"Number of messages rejected over SMTP by reason.",
+			"reason",
+		),
+		rcptsRejected: newCounter(
+			"zinktray_smtp_recipients_rejected_total",
+			"Number of recipients rejected over SMTP by reason.",
+			"reason",
+		),
+		receivedBytes: newCounter(
+			"zinktray_smtp_received_bytes_total",
+			"Total size of messages accepted over SMTP, in bytes.",
+		),
+		apiRequests: newCounter(
+			"zinktray_api_requests_total",
+			"Number of HTTP requests by route and response status.",
+			"route",
+			"status",
+		),
+	}
+}
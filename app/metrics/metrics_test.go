@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"zinktray/app/storage"
+)
+
+func TestWrite(t *testing.T) {
+	var metrics = NewMetrics()
+	var output bytes.Buffer
+
+	metrics.Write(&output, storage.Stats{})
+
+	// Counters without labels, as well as gauges, are exposed before anything is counted.
+	for _, expected := range []string{
+		"# HELP zinktray_smtp_connections_total Number of accepted SMTP connections.\n" +
+			"# TYPE zinktray_smtp_connections_total counter\n" +
+			"zinktray_smtp_connections_total 0\n",
+		"# TYPE zinktray_smtp_auth_total counter\n# HELP",
+		"zinktray_messages 0\n",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Output is expected to contain %q, got %q", expected, output.String())
+		}
+	}
+
+	metrics.SmtpConnection()
+	metrics.SmtpConnection()
+	metrics.SmtpAuth(true)
+	metrics.SmtpAuth(false)
+	metrics.MessageAccepted(120)
+	metrics.MessageAccepted(30)
+	metrics.MessageRejected("too_large")
+	metrics.MessageRejected("say \"hi\"\n")
+	metrics.RecipientRejected("unroutable")
+	metrics.RecipientRejected("unroutable")
+
+	output.Reset()
+	metrics.Write(&output, storage.Stats{Mailboxes: 2, Messages: 3, Size: 150, CompressedSize: 90})
+
+	for _, expected := range []string{
+		"zinktray_smtp_connections_total 2\n",
+		"zinktray_smtp_auth_total{result=\"failure\"} 1\nzinktray_smtp_auth_total{result=\"success\"} 1\n",
+		"zinktray_smtp_messages_accepted_total 2\n",
+		"zinktray_smtp_received_bytes_total 150\n",
+		"zinktray_smtp_messages_rejected_total{reason=\"say \\\"hi\\\"\\n\"} 1\n",
+		"zinktray_smtp_messages_rejected_total{reason=\"too_large\"} 1\n",
+		"zinktray_smtp_recipients_rejected_total{reason=\"unroutable\"} 2\n",
+		"# TYPE zinktray_mailboxes gauge\nzinktray_mailboxes 2\n",
+		"zinktray_messages 3\n",
+		"zinktray_stored_bytes 150\n",
+		"zinktray_stored_compressed_bytes 90\n",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Output is expected to contain %q, got %q", expected, output.String())
+		}
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	var metrics = NewMetrics()
+
+	var handler = metrics.Instrument("/api/messages/get", http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if request.URL.Query().Get("id") == "" {
+			response.WriteHeader(http.StatusBadRequest)
+			response.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		response.Write([]byte("{}"))
+	}))
+
+	for _, target := range []string{"/api/messages/get?id=1", "/api/messages/get?id=2", "/api/messages/get"} {
+		var recorder = httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	var output bytes.Buffer
+
+	metrics.Write(&output, storage.Stats{})
+
+	for _, expected := range []string{
+		"zinktray_api_requests_total{route=\"/api/messages/get\",status=\"200\"} 2\n",
+		"zinktray_api_requests_total{route=\"/api/messages/get\",status=\"400\"} 1\n",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Output is expected to contain %q, got %q", expected, output.String())
+		}
+	}
+
+	if strings.Contains(output.String(), "status=\"500\"") {
+		t.Errorf("Only the first status written is expected to be counted, got %q", output.String())
+	}
+}
@@ -0,0 +1,133 @@
+package pop3
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"zinktray/app/certificate"
+	"zinktray/app/config"
+	"zinktray/app/storage"
+)
+
+// Pop3Server structure represents a POP3 server implementation.
+//
+// Handles start and termination of POP3 listener and client sessions.
+type Pop3Server struct {
+	// certificate contains TLS certificate served by the server.
+	certificate *certificate.Bundle
+
+	// config contains POP3 server configuration.
+	config config.Pop3Config
+
+	// store provides central message storage.
+	store storage.Storage
+
+	// mutex guards conns and locks.
+	mutex sync.Mutex
+
+	// conns contains connections of active client sessions.
+	conns map[net.Conn]struct{}
+
+	// locks contains IDs of mailboxes locked by authenticated sessions.
+	locks map[string]struct{}
+}
+
+// Start wires-up POP3 server.
+//
+// Does nothing when no listen address is configured. The listener and every active session are terminated as soon
+// as ctx is cancelled.
+func (srv *Pop3Server) Start(ctx context.Context, waitGroup *sync.WaitGroup) {
+	defer waitGroup.Done()
+
+	if srv.config.Addr == "" {
+		return
+	}
+
+	listener, err := net.Listen("tcp", srv.config.Addr)
+
+	if err != nil {
+		log.Fatalf("POP3 server failed to start: %s", err)
+	}
+
+	go srv.serve(listener)
+
+	<-ctx.Done()
+
+	if err := listener.Close(); err != nil {
+		log.Fatalf("Cannot shutdown POP3 server: %s", err)
+	}
+
+	srv.mutex.Lock()
+
+	defer srv.mutex.Unlock()
+
+	for conn := range srv.conns {
+		conn.Close()
+	}
+}
+
+// serve accepts client connections until listener is closed.
+func (srv *Pop3Server) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+
+		if errors.Is(err, net.ErrClosed) {
+			return
+		} else if err != nil {
+			log.Printf("Cannot accept POP3 connection: %s", err)
+
+			continue
+		}
+
+		srv.mutex.Lock()
+		srv.conns[conn] = struct{}{}
+		srv.mutex.Unlock()
+
+		go func() {
+			newSession(srv, conn).serve()
+
+			srv.mutex.Lock()
+			delete(srv.conns, conn)
+			srv.mutex.Unlock()
+		}()
+	}
+}
+
+// lock acquires exclusive access to mailbox with provided ID. Returns false when mailbox is already locked.
+func (srv *Pop3Server) lock(mailboxID string) bool {
+	srv.mutex.Lock()
+
+	defer srv.mutex.Unlock()
+
+	if _, ok := srv.locks[mailboxID]; ok {
+		return false
+	}
+
+	srv.locks[mailboxID] = struct{}{}
+
+	return true
+}
+
+// unlock releases exclusive access to mailbox with provided ID.
+func (srv *Pop3Server) unlock(mailboxID string) {
+	srv.mutex.Lock()
+
+	defer srv.mutex.Unlock()
+
+	delete(srv.locks, mailboxID)
+}
+
+// NewServer creates new POP3 server structure.
+//
+// certificate is only required when STLS command is enabled.
+func NewServer(storage storage.Storage, config config.Pop3Config, certificate *certificate.Bundle) *Pop3Server {
+	return &Pop3Server{
+		certificate: certificate,
+		config:      config,
+		store:       storage,
+		conns:       make(map[net.Conn]struct{}),
+		locks:       make(map[string]struct{}),
+	}
+}
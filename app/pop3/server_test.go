@@ -0,0 +1,304 @@
+package pop3
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"zinktray/app/certificate"
+	"zinktray/app/config"
+	"zinktray/app/message"
+	"zinktray/app/storage"
+)
+
+var rawMessages = []string{
+	"Subject: First\r\n\r\nFirst line\r\nSecond line\r\n",
+	"Subject: Second\n\n.hidden\nlast\n",
+}
+
+func TestPop3(t *testing.T) {
+	var store = storage.NewMemoryStorage()
+	var cfg = config.Default().POP3
+	var msgIDs = addMessages(t, store, "test", rawMessages)
+
+	cfg.Addr = "127.0.0.1:1110"
+
+	var cancel = newServer(store, cfg, nil)
+
+	t.Cleanup(cancel)
+
+	t.Run("auth", func(t *testing.T) {
+		var client = newClient(cfg.Addr)
+
+		defer client.Close()
+
+		expectError(t, client, "STAT")
+		expectOK(t, client, "USER test")
+		expectOK(t, client, "PASS secret")
+
+		var other = newClient(cfg.Addr)
+
+		defer other.Close()
+
+		expectOK(t, other, "USER test")
+		expectError(t, other, "PASS secret")
+		expectOK(t, client, "QUIT")
+	})
+
+	t.Run("listing", func(t *testing.T) {
+		var client = login(t, cfg.Addr, "test")
+
+		defer logout(t, client)
+
+		if text := expectOK(t, client, "STAT"); text != "2 77" {
+			t.Errorf("Unexpected maildrop status: got \"%s\", expected \"%s\"", text, "2 77")
+		}
+
+		if lines := expectLines(t, client, "LIST"); strings.Join(lines, ",") != "1 43,2 34" {
+			t.Errorf("Unexpected scan listing: %v", lines)
+		}
+
+		if text := expectOK(t, client, "UIDL 2"); text != "2 "+msgIDs[1] {
+			t.Errorf("Unexpected unique ID listing: got \"%s\", expected \"%s\"", text, "2 "+msgIDs[1])
+		}
+
+		expectError(t, client, "LIST 3")
+	})
+
+	t.Run("retrieval", func(t *testing.T) {
+		var client = login(t, cfg.Addr, "test")
+
+		defer logout(t, client)
+
+		var lines = expectLines(t, client, "RETR 2")
+
+		if strings.Join(lines, "\n") != "Subject: Second\n\n.hidden\nlast" {
+			t.Errorf("Unexpected message contents: %q", lines)
+		}
+
+		lines = expectLines(t, client, "TOP 1 1")
+
+		if strings.Join(lines, "\n") != "Subject: First\n\nFirst line" {
+			t.Errorf("Unexpected message top: %q", lines)
+		}
+	})
+
+	t.Run("deletion", func(t *testing.T) {
+		var client = login(t, cfg.Addr, "test")
+
+		defer client.Close()
+
+		expectOK(t, client, "DELE 1")
+		expectError(t, client, "RETR 1")
+		expectOK(t, client, "RSET")
+		expectOK(t, client, "DELE 2")
+
+		if store.CountMessages("test") != 2 {
+			t.Fatal("Messages must not be deleted before session is over")
+		}
+
+		expectOK(t, client, "QUIT")
+
+		if store.GetMessage(msgIDs[0]) == nil || store.GetMessage(msgIDs[1]) != nil {
+			t.Fatal("Only message marked as deleted is expected to be deleted upon quitting")
+		}
+	})
+}
+
+func TestPop3StartTLS(t *testing.T) {
+	var store = storage.NewMemoryStorage()
+	var cfg = config.Default().POP3
+
+	addMessages(t, store, "test", rawMessages)
+
+	cfg.Addr = "127.0.0.1:1111"
+	cfg.StartTLS = true
+
+	var bundle, err = certificate.Generate([]string{"localhost", "127.0.0.1"})
+
+	if err != nil {
+		t.Fatalf("Cannot generate certificate: %s", err)
+	}
+
+	var cancel = newServer(store, cfg, bundle)
+
+	t.Cleanup(cancel)
+
+	var roots = x509.NewCertPool()
+
+	if !roots.AppendCertsFromPEM(bundle.TrustPEM) {
+		t.Fatal("Cannot parse trusted certificate")
+	}
+
+	var conn = dial(cfg.Addr)
+	var client = textproto.NewConn(conn)
+
+	defer client.Close()
+
+	readResponse(t, client)
+
+	if lines := expectLines(t, client, "CAPA"); !strings.Contains(strings.Join(lines, ","), "STLS") {
+		t.Fatalf("STLS capability is expected to be advertised: %v", lines)
+	}
+
+	expectOK(t, client, "STLS")
+
+	var tlsConn = tls.Client(conn, &tls.Config{RootCAs: roots, ServerName: "127.0.0.1"})
+
+	if err := tlsConn.Handshake(); err != nil {
+		t.Fatalf("Cannot start TLS: %s", err)
+	}
+
+	client = textproto.NewConn(tlsConn)
+
+	expectOK(t, client, "USER test")
+	expectOK(t, client, "PASS secret")
+
+	if text := expectOK(t, client, "STAT"); !strings.HasPrefix(text, "2 ") {
+		t.Errorf("Unexpected maildrop status: %s", text)
+	}
+}
+
+// addMessages stores messages into mailbox and returns their IDs in order of addition.
+func addMessages(t *testing.T, store storage.Storage, mailboxID string, rawData []string) []string {
+	var ids = make([]string, 0, len(rawData))
+
+	store.AddMailbox(mailboxID)
+
+	for _, data := range rawData {
+		var msg = message.NewMessage(data)
+
+		if err := store.AddMessage(msg, mailboxID); err != nil {
+			t.Fatalf("Cannot store message: %s", err)
+		}
+
+		ids = append(ids, msg.ID)
+	}
+
+	return ids
+}
+
+// login opens authenticated session.
+func login(t *testing.T, addr string, username string) *textproto.Conn {
+	var client = newClient(addr)
+
+	expectOK(t, client, "USER "+username)
+	expectOK(t, client, "PASS secret")
+
+	return client
+}
+
+// logout quits the session and closes connection.
+func logout(t *testing.T, client *textproto.Conn) {
+	expectOK(t, client, "QUIT")
+
+	client.Close()
+}
+
+// expectOK issues command and returns text of successful response.
+func expectOK(t *testing.T, client *textproto.Conn, command string) string {
+	t.Helper()
+
+	var ok, text = issue(t, client, command)
+
+	if !ok {
+		t.Fatalf("Command \"%s\" failed: %s", command, text)
+	}
+
+	return text
+}
+
+// expectError issues command expected to fail.
+func expectError(t *testing.T, client *textproto.Conn, command string) {
+	t.Helper()
+
+	if ok, _ := issue(t, client, command); ok {
+		t.Fatalf("Command \"%s\" is expected to fail", command)
+	}
+}
+
+// expectLines issues command and returns lines of successful multi-line response.
+func expectLines(t *testing.T, client *textproto.Conn, command string) []string {
+	t.Helper()
+
+	expectOK(t, client, command)
+
+	var lines, err = client.ReadDotLines()
+
+	if err != nil {
+		t.Fatalf("Cannot read response of command \"%s\": %s", command, err)
+	}
+
+	return lines
+}
+
+func issue(t *testing.T, client *textproto.Conn, command string) (bool, string) {
+	t.Helper()
+
+	if err := client.PrintfLine("%s", command); err != nil {
+		t.Fatalf("Cannot issue command \"%s\": %s", command, err)
+	}
+
+	return readResponse(t, client)
+}
+
+func readResponse(t *testing.T, client *textproto.Conn) (bool, string) {
+	t.Helper()
+
+	var line, err = client.ReadLine()
+
+	if err != nil {
+		t.Fatalf("Cannot read response: %s", err)
+	}
+
+	if text, ok := strings.CutPrefix(line, "+OK"); ok {
+		return true, strings.TrimSpace(text)
+	}
+
+	return false, strings.TrimSpace(strings.TrimPrefix(line, "-ERR"))
+}
+
+func newServer(storage storage.Storage, cfg config.Pop3Config, bundle *certificate.Bundle) context.CancelFunc {
+	var ctx, cancel = context.WithCancel(context.Background())
+	var server = NewServer(storage, cfg, bundle)
+	var wg = &sync.WaitGroup{}
+
+	wg.Add(1)
+
+	go func() {
+		server.Start(ctx, wg)
+	}()
+
+	return cancel
+}
+
+func newClient(addr string) *textproto.Conn {
+	var client = textproto.NewConn(dial(addr))
+
+	if line, err := client.ReadLine(); err != nil || !strings.HasPrefix(line, "+OK") {
+		panic(fmt.Sprintf("Unexpected greeting: %s %v", line, err))
+	}
+
+	return client
+}
+
+func dial(addr string) net.Conn {
+	var conn net.Conn
+	var err error
+
+	for i := 3; i > 0; i-- {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			return conn
+		}
+
+		time.Sleep(150 * time.Millisecond)
+	}
+
+	panic(fmt.Sprintf("Cannot dial %s: %s", addr, err))
+}
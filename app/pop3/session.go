@@ -0,0 +1,479 @@
+package pop3
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pop3Session represents information on individual POP3 session.
+type pop3Session struct {
+	// server contains the server accepted the session.
+	server *Pop3Server
+
+	// conn contains underlying client connection.
+	conn net.Conn
+
+	// reader reads client commands from conn.
+	reader *bufio.Reader
+
+	// writer writes server responses to conn.
+	writer *bufio.Writer
+
+	// isTLS tells whether the session is conducted over TLS.
+	isTLS bool
+
+	// username contains username given in USER command.
+	username string
+
+	// mailboxID contains ID of the mailbox locked by authentication. Empty until authenticated.
+	mailboxID string
+
+	// messages contains maildrop listing taken upon authentication, oldest first.
+	messages []*maildropMessage
+}
+
+// maildropMessage represents information on message listed in maildrop.
+type maildropMessage struct {
+	// id contains ID of stored message.
+	id string
+
+	// size contains size of the message with CRLF line endings, in bytes.
+	size int
+
+	// deleted tells whether the message is marked as deleted.
+	deleted bool
+}
+
+// serve conducts the session until client quits or connection fails.
+func (session *pop3Session) serve() {
+	defer session.conn.Close()
+
+	defer func() {
+		if session.mailboxID != "" {
+			session.server.unlock(session.mailboxID)
+		}
+	}()
+
+	session.reply(true, "ZinkTray POP3 server ready")
+
+	for {
+		if err := session.writer.Flush(); err != nil {
+			return
+		}
+
+		timeout := time.Duration(session.server.config.Timeout)
+
+		if err := session.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return
+		}
+
+		line, err := session.reader.ReadString('\n')
+
+		if err != nil {
+			return
+		}
+
+		command, args, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+
+		if !session.handle(strings.ToUpper(command), strings.Fields(args)) {
+			return
+		}
+	}
+}
+
+// handle executes client command. Returns false when the session is to be terminated.
+func (session *pop3Session) handle(command string, args []string) bool {
+	switch command {
+	case "QUIT":
+		session.quit()
+
+		return false
+	case "CAPA":
+		session.capa()
+
+		return true
+	case "NOOP":
+		session.reply(true, "")
+
+		return true
+	}
+
+	if session.mailboxID == "" {
+		switch command {
+		case "USER":
+			session.user(args)
+		case "PASS":
+			session.pass(args)
+		case "STLS":
+			return session.stls()
+		default:
+			session.reply(false, "Command is not allowed before authentication")
+		}
+
+		return true
+	}
+
+	switch command {
+	case "STAT":
+		session.stat()
+	case "LIST":
+		session.list(args)
+	case "UIDL":
+		session.uidl(args)
+	case "RETR":
+		session.retr(args)
+	case "TOP":
+		session.top(args)
+	case "DELE":
+		session.dele(args)
+	case "RSET":
+		session.rset()
+	default:
+		session.reply(false, "Unknown command")
+	}
+
+	return true
+}
+
+func (session *pop3Session) capa() {
+	capabilities := []string{"TOP", "UIDL", "USER", "RESP-CODES"}
+
+	if session.canStartTLS() {
+		capabilities = append(capabilities, "STLS")
+	}
+
+	capabilities = append(capabilities, "IMPLEMENTATION ZinkTray")
+
+	session.reply(true, "Capability list follows")
+	session.writeLines(capabilities)
+}
+
+func (session *pop3Session) user(args []string) {
+	if len(args) != 1 {
+		session.reply(false, "Username is mandatory")
+
+		return
+	}
+
+	session.username = args[0]
+
+	session.reply(true, "")
+}
+
+func (session *pop3Session) pass(args []string) {
+	// Any password is accepted, mailbox is selected by username.
+	if session.username == "" {
+		session.reply(false, "USER command must come first")
+
+		return
+	}
+
+	mbox := session.server.store.GetMailbox(session.username)
+	if mbox == nil {
+		mbox = session.server.store.AddMailbox(session.username)
+	}
+
+	if !session.server.lock(mbox.ID) {
+		session.username = ""
+
+		session.reply(false, "[IN-USE] Mailbox is already locked")
+
+		return
+	}
+
+	session.mailboxID = mbox.ID
+
+	stored := session.server.store.GetMessages(mbox.ID)
+
+	// Stored messages go newest first, while maildrop lists them oldest first.
+	for i := len(stored) - 1; i >= 0; i-- {
+		session.messages = append(session.messages, &maildropMessage{
+			id:   stored[i].ID,
+			size: len(normalizeLines(stored[i].GetRawData())),
+		})
+	}
+
+	session.reply(true, "Mailbox locked and ready")
+}
+
+func (session *pop3Session) stls() bool {
+	if !session.canStartTLS() {
+		session.reply(false, "Command is not available")
+
+		return true
+	}
+
+	session.reply(true, "Begin TLS negotiation")
+
+	if err := session.writer.Flush(); err != nil {
+		return false
+	}
+
+	tlsConn := tls.Server(session.conn, session.server.certificate.TLSConfig())
+
+	if err := tlsConn.Handshake(); err != nil {
+		log.Printf("POP3 TLS handshake failed: %s", err)
+
+		return false
+	}
+
+	session.conn = tlsConn
+	session.reader = bufio.NewReader(tlsConn)
+	session.writer = bufio.NewWriter(tlsConn)
+	session.isTLS = true
+
+	return true
+}
+
+func (session *pop3Session) stat() {
+	count, size := 0, 0
+
+	for _, msg := range session.messages {
+		if !msg.deleted {
+			count++
+			size += msg.size
+		}
+	}
+
+	session.reply(true, fmt.Sprintf("%d %d", count, size))
+}
+
+func (session *pop3Session) list(args []string) {
+	session.listing(args, func(number int, msg *maildropMessage) string {
+		return fmt.Sprintf("%d %d", number, msg.size)
+	})
+}
+
+func (session *pop3Session) uidl(args []string) {
+	session.listing(args, func(number int, msg *maildropMessage) string {
+		return fmt.Sprintf("%d %s", number, msg.id)
+	})
+}
+
+// listing replies with scan listing of a single message given in args, or of every message not marked as deleted.
+func (session *pop3Session) listing(args []string, format func(number int, msg *maildropMessage) string) {
+	if len(args) > 0 {
+		if number, msg := session.message(args[0]); msg != nil {
+			session.reply(true, format(number, msg))
+		}
+
+		return
+	}
+
+	lines := make([]string, 0, len(session.messages))
+
+	for i, msg := range session.messages {
+		if !msg.deleted {
+			lines = append(lines, format(i+1, msg))
+		}
+	}
+
+	session.reply(true, "Listing follows")
+	session.writeLines(lines)
+}
+
+func (session *pop3Session) retr(args []string) {
+	if len(args) != 1 {
+		session.reply(false, "Message number is mandatory")
+
+		return
+	}
+
+	if lines, ok := session.contents(args[0]); ok {
+		session.reply(true, "Message follows")
+		session.writeLines(lines)
+	}
+}
+
+func (session *pop3Session) top(args []string) {
+	if len(args) != 2 {
+		session.reply(false, "Message number and line count are mandatory")
+
+		return
+	}
+
+	count, err := strconv.Atoi(args[1])
+
+	if err != nil || count < 0 {
+		session.reply(false, "Invalid line count")
+
+		return
+	}
+
+	lines, ok := session.contents(args[0])
+
+	if !ok {
+		return
+	}
+
+	// Headers are separated from body with the first empty line.
+	headerCount := slices.Index(lines, "")
+
+	if headerCount < 0 {
+		headerCount = len(lines)
+	} else {
+		headerCount++
+	}
+
+	session.reply(true, "Top of message follows")
+	session.writeLines(lines[:min(len(lines), headerCount+count)])
+}
+
+func (session *pop3Session) dele(args []string) {
+	if len(args) != 1 {
+		session.reply(false, "Message number is mandatory")
+
+		return
+	}
+
+	if _, msg := session.message(args[0]); msg != nil {
+		msg.deleted = true
+
+		session.reply(true, "Message marked as deleted")
+	}
+}
+
+func (session *pop3Session) rset() {
+	for _, msg := range session.messages {
+		msg.deleted = false
+	}
+
+	session.reply(true, "")
+}
+
+func (session *pop3Session) quit() {
+	// Messages are deleted only upon quitting authenticated session.
+	for _, msg := range session.messages {
+		if msg.deleted {
+			session.server.store.DeleteMessage(msg.id)
+		}
+	}
+
+	if session.mailboxID != "" {
+		session.server.unlock(session.mailboxID)
+
+		session.mailboxID = ""
+	}
+
+	session.reply(true, "Bye")
+	session.writer.Flush()
+}
+
+// canStartTLS tells whether STLS command is available.
+func (session *pop3Session) canStartTLS() bool {
+	return session.server.config.StartTLS &&
+		session.server.certificate != nil &&
+		!session.isTLS &&
+		session.mailboxID == ""
+}
+
+// message returns maildrop message with provided number along with parsed number.
+//
+// Replies with an error and returns nil when there is no such message or it is marked as deleted.
+func (session *pop3Session) message(arg string) (int, *maildropMessage) {
+	number, err := strconv.Atoi(arg)
+
+	if err != nil || number < 1 || number > len(session.messages) {
+		session.reply(false, "No such message")
+
+		return 0, nil
+	}
+
+	msg := session.messages[number-1]
+
+	if msg.deleted {
+		session.reply(false, "Message is marked as deleted")
+
+		return 0, nil
+	}
+
+	return number, msg
+}
+
+// contents returns lines of maildrop message with provided number.
+//
+// Replies with an error and returns false when there is no such message or it has been removed from storage since.
+func (session *pop3Session) contents(arg string) ([]string, bool) {
+	_, msg := session.message(arg)
+
+	if msg == nil {
+		return nil, false
+	}
+
+	stored := session.server.store.GetMessage(msg.id)
+
+	if stored == nil {
+		session.reply(false, "Message has been removed")
+
+		return nil, false
+	}
+
+	return splitLines(stored.GetRawData()), true
+}
+
+// reply writes single-line response.
+func (session *pop3Session) reply(ok bool, text string) {
+	status := "+OK"
+
+	if !ok {
+		status = "-ERR"
+	}
+
+	if text != "" {
+		status += " " + text
+	}
+
+	session.writer.WriteString(status + "\r\n")
+}
+
+// writeLines writes multi-line response body along with termination line, dot-stuffing lines as necessary.
+func (session *pop3Session) writeLines(lines []string) {
+	for _, line := range lines {
+		if strings.HasPrefix(line, ".") {
+			session.writer.WriteString(".")
+		}
+
+		session.writer.WriteString(line + "\r\n")
+	}
+
+	session.writer.WriteString(".\r\n")
+}
+
+// splitLines splits raw message contents into lines regardless of line endings used.
+func splitLines(rawData string) []string {
+	lines := strings.Split(strings.ReplaceAll(rawData, "\r\n", "\n"), "\n")
+
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// normalizeLines returns raw message contents with CRLF line endings.
+func normalizeLines(rawData string) string {
+	var builder strings.Builder
+
+	for _, line := range splitLines(rawData) {
+		builder.WriteString(line + "\r\n")
+	}
+
+	return builder.String()
+}
+
+// newSession creates new POP3 session structure over client connection.
+func newSession(server *Pop3Server, conn net.Conn) *pop3Session {
+	return &pop3Session{
+		server: server,
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		writer: bufio.NewWriter(conn),
+	}
+}
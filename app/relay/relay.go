@@ -0,0 +1,145 @@
+package relay
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"slices"
+	"strings"
+	"zinktray/app/config"
+	"zinktray/app/message"
+
+	"github.com/emersion/go-sasl"
+	"github.com/emersion/go-smtp"
+)
+
+// ErrDisabled is returned upon releasing a message when no upstream relay is configured.
+var ErrDisabled = errors.New("message release is disabled")
+
+// ErrNoRecipients is returned upon releasing a message to no recipients.
+var ErrNoRecipients = errors.New("no recipients given")
+
+// ErrInvalidRecipient is returned upon releasing a message to malformed address.
+var ErrInvalidRecipient = errors.New("malformed recipient address")
+
+// ErrRecipientNotAllowed is returned upon releasing a message to address outside of allowed domains.
+var ErrRecipientNotAllowed = errors.New("recipient domain is not allowed")
+
+// Relay structure represents upstream SMTP relay stored messages are released to.
+type Relay struct {
+	// config contains upstream relay configuration.
+	config config.RelayConfig
+}
+
+// IsEnabled tells whether upstream relay is configured.
+func (relay *Relay) IsEnabled() bool {
+	return relay.config.Addr != ""
+}
+
+// Release delivers stored message to recipients via upstream relay.
+//
+// Message is relayed as is. Envelope sender is the one message has been received with, or the address found in From
+// header when message has not been received via SMTP. Every recipient must belong to one of allowed domains.
+func (relay *Relay) Release(msg *message.Message, recipients []string) error {
+	if !relay.IsEnabled() {
+		return ErrDisabled
+	}
+
+	if len(recipients) == 0 {
+		return ErrNoRecipients
+	}
+
+	for _, rcpt := range recipients {
+		if err := relay.checkRecipient(rcpt); err != nil {
+			return err
+		}
+	}
+
+	client, err := relay.dial()
+
+	if err != nil {
+		return fmt.Errorf("cannot connect to %s: %w", relay.config.Addr, err)
+	}
+
+	defer client.Close()
+
+	if relay.config.Username != "" {
+		if err := client.Auth(sasl.NewPlainClient("", relay.config.Username, relay.config.Password)); err != nil {
+			return fmt.Errorf("cannot authenticate: %w", err)
+		}
+	}
+
+	if err := client.SendMail(sender(msg), recipients, strings.NewReader(msg.GetRawData())); err != nil {
+		return fmt.Errorf("cannot relay message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// checkRecipient tests whether message may be released to recipient address.
+func (relay *Relay) checkRecipient(rcpt string) error {
+	addr, err := mail.ParseAddress(rcpt)
+
+	if err != nil || addr.Address != rcpt {
+		return fmt.Errorf("%w: %q", ErrInvalidRecipient, rcpt)
+	}
+
+	_, domain, _ := strings.Cut(rcpt, "@")
+
+	allowed := slices.ContainsFunc(relay.config.AllowedDomains, func(allowedDomain string) bool {
+		return strings.EqualFold(domain, allowedDomain)
+	})
+
+	if !allowed {
+		return fmt.Errorf("%w: %q", ErrRecipientNotAllowed, rcpt)
+	}
+
+	return nil
+}
+
+// dial connects to upstream server according to configured TLS mode.
+func (relay *Relay) dial() (*smtp.Client, error) {
+	host, _, _ := net.SplitHostPort(relay.config.Addr)
+
+	tlsConfig := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: relay.config.TLSSkipVerify,
+	}
+
+	switch relay.config.TLS {
+	case config.RelayTLSStartTLS:
+		return smtp.DialStartTLS(relay.config.Addr, tlsConfig)
+	case config.RelayTLSImplicit:
+		return smtp.DialTLS(relay.config.Addr, tlsConfig)
+	default:
+		return smtp.Dial(relay.config.Addr)
+	}
+}
+
+// sender returns envelope sender address of released message.
+func sender(msg *message.Message) string {
+	if msg.Envelope != nil {
+		return msg.Envelope.ReturnPath
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(msg.GetRawData()))
+
+	if err != nil {
+		return ""
+	}
+
+	if from, err := parsed.Header.AddressList("From"); err == nil && len(from) > 0 {
+		return from[0].Address
+	}
+
+	return ""
+}
+
+// NewRelay creates new upstream relay structure.
+func NewRelay(config config.RelayConfig) *Relay {
+	return &Relay{
+		config: config,
+	}
+}
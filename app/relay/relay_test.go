@@ -0,0 +1,139 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+	"zinktray/app/certificate"
+	"zinktray/app/config"
+	"zinktray/app/message"
+	"zinktray/app/metrics"
+	"zinktray/app/smtp"
+	"zinktray/app/storage"
+)
+
+var rawMessage = "From: Alice <alice@example.com>\r\nTo: bob@example.com\r\nSubject: Test\r\n\r\n.leading dot\r\n"
+
+func TestRelease(t *testing.T) {
+	var upstream = storage.NewMemoryStorage()
+	var smtpConfig = config.Default().SMTP
+
+	smtpConfig.Addr = "127.0.0.1:2532"
+	smtpConfig.StartTLS = true
+	smtpConfig.TLSAddr = "127.0.0.1:2533"
+
+	var cancel = newServer(t, upstream, smtpConfig)
+
+	t.Cleanup(cancel)
+
+	var cfg = config.Default().Relay
+
+	cfg.Username = "upstream"
+	cfg.TLSSkipVerify = true
+	cfg.AllowedDomains = []string{"example.com"}
+
+	var modes = map[string]string{
+		config.RelayTLSNone:     smtpConfig.Addr,
+		config.RelayTLSStartTLS: smtpConfig.Addr,
+		config.RelayTLSImplicit: smtpConfig.TLSAddr,
+	}
+
+	for mode, addr := range modes {
+		t.Run(mode, func(t *testing.T) {
+			var cfg = cfg
+			var msg = message.NewMessage(rawMessage)
+
+			cfg.Addr = addr
+			cfg.TLS = mode
+
+			if err := NewRelay(cfg).Release(msg, []string{"carol@Example.com"}); err != nil {
+				t.Fatalf("Cannot release message: %s", err)
+			}
+		})
+	}
+
+	var released = upstream.GetMessages("upstream")
+
+	if len(released) != len(modes) {
+		t.Fatalf("Unexpected number of released messages: got %d, expected %d", len(released), len(modes))
+	}
+
+	for _, msg := range released {
+		if msg.GetRawData() != rawMessage {
+			t.Errorf("Released message is expected to be relayed as is, got %q", msg.GetRawData())
+		}
+
+		if msg.Envelope.ReturnPath != "alice@example.com" {
+			t.Errorf("Unexpected envelope sender: %s", msg.Envelope.ReturnPath)
+		}
+
+		if len(msg.Envelope.Recipients) != 1 || msg.Envelope.Recipients[0].Address != "carol@Example.com" {
+			t.Errorf("Unexpected envelope recipients: %+v", msg.Envelope.Recipients)
+		}
+	}
+}
+
+func TestReleaseRejected(t *testing.T) {
+	var msg = message.NewMessage(rawMessage)
+	var cfg = config.Default().Relay
+
+	if err := NewRelay(cfg).Release(msg, []string{"bob@example.com"}); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Unexpected error: expected \"%s\", got \"%v\"", ErrDisabled, err)
+	}
+
+	// Nothing listens on the port, so that any connection attempt fails.
+	cfg.Addr = "127.0.0.1:2534"
+	cfg.AllowedDomains = []string{"example.com"}
+
+	var errorsExpected = map[string]error{
+		"bob@example.org":          ErrRecipientNotAllowed,
+		"bob@mail.example.com":     ErrRecipientNotAllowed,
+		"Bob <bob@example.com>":    ErrInvalidRecipient,
+		"not an address":           ErrInvalidRecipient,
+		"bob@example.com.attacker": ErrRecipientNotAllowed,
+	}
+
+	for rcpt, expected := range errorsExpected {
+		if err := NewRelay(cfg).Release(msg, []string{"bob@example.com", rcpt}); !errors.Is(err, expected) {
+			t.Errorf("Unexpected error for %q: expected \"%s\", got \"%v\"", rcpt, expected, err)
+		}
+	}
+
+	if err := NewRelay(cfg).Release(msg, nil); !errors.Is(err, ErrNoRecipients) {
+		t.Errorf("Unexpected error: expected \"%s\", got \"%v\"", ErrNoRecipients, err)
+	}
+}
+
+// newServer starts upstream SMTP server and waits for it to accept connections.
+func newServer(t *testing.T, store storage.Storage, cfg config.SmtpConfig) context.CancelFunc {
+	var ctx, cancel = context.WithCancel(context.Background())
+	var wg = &sync.WaitGroup{}
+
+	var bundle, err = certificate.Generate([]string{"localhost", "127.0.0.1"})
+
+	if err != nil {
+		t.Fatalf("Cannot generate certificate: %s", err)
+	}
+
+	wg.Add(1)
+
+	go smtp.NewServer(store, cfg, bundle, metrics.NewMetrics()).Start(ctx, wg)
+
+	for i := 3; i > 0; i-- {
+		if conn, err := net.Dial("tcp", cfg.Addr); err == nil {
+			conn.Close()
+
+			return cancel
+		}
+
+		time.Sleep(150 * time.Millisecond)
+	}
+
+	cancel()
+	t.Fatalf("SMTP server is not listening on %s", cfg.Addr)
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package retention
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+	"zinktray/app/config"
+	"zinktray/app/message"
+	"zinktray/app/storage"
+)
+
+// Janitor evicts stored messages exceeding retention limits.
+type Janitor struct {
+	// config contains retention configuration.
+	config config.RetentionConfig
+
+	// store contains storage messages are evicted from.
+	store storage.Storage
+}
+
+// storedMessage describes stored message along with the mailbox it is bound to.
+type storedMessage struct {
+	msg       *message.Message
+	mailboxID string
+}
+
+// Start wires-up periodic eviction of messages exceeding retention limits.
+//
+// Janitor is terminated as soon as ctx is cancelled. Does nothing when no limit is set.
+func (janitor *Janitor) Start(ctx context.Context, waitGroup *sync.WaitGroup) {
+	defer waitGroup.Done()
+
+	if !janitor.config.IsEnabled() {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(janitor.config.Interval))
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			janitor.Sweep(now)
+		}
+	}
+}
+
+// Sweep evicts messages exceeding retention limits at provided moment and returns the number of messages evicted.
+//
+// Limits of every mailbox are enforced first, and global limits are enforced on the remaining messages then. Messages
+// are evicted oldest first.
+func (janitor *Janitor) Sweep(now time.Time) int {
+	evicted := 0
+	remaining := make([]storedMessage, 0)
+
+	for _, mbx := range janitor.store.GetMailboxes() {
+		messages := janitor.store.GetMessages(mbx.ID)
+		stored := make([]storedMessage, 0, len(messages))
+
+		// Mailbox messages go newest first.
+		for i := len(messages) - 1; i >= 0; i-- {
+			stored = append(stored, storedMessage{msg: messages[i], mailboxID: mbx.ID})
+		}
+
+		kept, count := janitor.enforce(stored, janitor.config.ForMailbox(mbx.ID), now, "mailbox")
+
+		remaining = append(remaining, kept...)
+		evicted += count
+	}
+
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return remaining[i].msg.ReceivedAt.Before(remaining[j].msg.ReceivedAt)
+	})
+
+	_, count := janitor.enforce(remaining, janitor.config.Global(), now, "global")
+
+	return evicted + count
+}
+
+// enforce evicts messages exceeding limits. Messages are expected to go oldest first. scope describes limits in log.
+//
+// Returns messages kept and the number of messages evicted.
+func (janitor *Janitor) enforce(
+	messages []storedMessage,
+	limits config.RetentionLimits,
+	now time.Time,
+	scope string,
+) ([]storedMessage, int) {
+	if limits.IsEmpty() {
+		return messages, 0
+	}
+
+	count := len(messages)
+	size := int64(0)
+
+	for _, stored := range messages {
+		size += int64(stored.msg.GetSummary().Size)
+	}
+
+	evicted := 0
+
+	for _, stored := range messages {
+		var reason string
+
+		switch {
+		case limits.MaxAge > 0 && now.Sub(stored.msg.ReceivedAt) > time.Duration(limits.MaxAge):
+			reason = "max age"
+		case limits.MaxMessages > 0 && count > limits.MaxMessages:
+			reason = "max messages"
+		case limits.MaxBytes > 0 && size > limits.MaxBytes:
+			reason = "max bytes"
+		default:
+			return messages[evicted:], evicted
+		}
+
+		janitor.store.DeleteMessage(stored.msg.ID)
+
+		log.Printf(
+			"Message \"%s\" evicted from mailbox \"%s\": %s %s limit exceeded\n",
+			stored.msg.ID,
+			stored.mailboxID,
+			scope,
+			reason,
+		)
+
+		count--
+		size -= int64(stored.msg.GetSummary().Size)
+		evicted++
+	}
+
+	return messages[evicted:], evicted
+}
+
+// NewJanitor creates new janitor structure.
+func NewJanitor(store storage.Storage, config config.RetentionConfig) *Janitor {
+	return &Janitor{
+		config: config,
+		store:  store,
+	}
+}
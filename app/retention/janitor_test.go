@@ -0,0 +1,102 @@
+package retention
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"zinktray/app/config"
+	"zinktray/app/message"
+	"zinktray/app/storage"
+)
+
+func TestSweep(t *testing.T) {
+	var now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var cases = []struct {
+		name     string
+		config   config.RetentionConfig
+		expected map[string][]string
+	}{
+		{
+			"no limits",
+			config.RetentionConfig{},
+			map[string][]string{"a": {"a4", "a3", "a2", "a1"}, "b": {"b2", "b1"}},
+		},
+		{
+			"global max messages",
+			config.RetentionConfig{MaxMessages: 3},
+			map[string][]string{"a": {"a4", "a3"}, "b": {"b2"}},
+		},
+		{
+			"global max bytes",
+			config.RetentionConfig{MaxBytes: 250},
+			map[string][]string{"a": {"a4"}, "b": {"b2"}},
+		},
+		{
+			"global max age",
+			config.RetentionConfig{MaxAge: config.Duration(200 * time.Minute)},
+			map[string][]string{"a": {"a4", "a3"}, "b": {"b2"}},
+		},
+		{
+			"mailbox max messages",
+			config.RetentionConfig{Mailbox: config.RetentionLimits{MaxMessages: 1}},
+			map[string][]string{"a": {"a4"}, "b": {"b2"}},
+		},
+		{
+			"mailbox override",
+			config.RetentionConfig{
+				Mailbox:   config.RetentionLimits{MaxMessages: 1},
+				Mailboxes: map[string]config.RetentionLimits{"a": {MaxBytes: 250}},
+			},
+			map[string][]string{"a": {"a4", "a3"}, "b": {"b2"}},
+		},
+		{
+			"mailbox and global",
+			config.RetentionConfig{MaxMessages: 2, Mailbox: config.RetentionLimits{MaxMessages: 3}},
+			map[string][]string{"a": {"a4"}, "b": {"b2"}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var store = storage.NewMemoryStorage()
+
+			// Every message is 100 bytes long, received an hour after the previous one: a1, b1, a2, a3, b2, a4.
+			for i, id := range []string{"a1", "b1", "a2", "a3", "b2", "a4"} {
+				msg := message.NewMessage(fmt.Sprintf("Subject: %s\r\n\r\n%s", id, strings.Repeat("x", 85)))
+				msg.ID = id
+				msg.ReceivedAt = now.Add(time.Duration(i-6) * time.Hour)
+
+				store.AddMailbox(id[:1])
+
+				if err := store.AddMessage(msg, id[:1]); err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+			}
+
+			var expectedEvicted = 6
+
+			for _, ids := range c.expected {
+				expectedEvicted -= len(ids)
+			}
+
+			if evicted := NewJanitor(store, c.config).Sweep(now); evicted != expectedEvicted {
+				t.Errorf("Number of evicted messages does not match: got %d, expected %d", evicted, expectedEvicted)
+			}
+
+			for mailboxID, expected := range c.expected {
+				var ids []string
+
+				for _, msg := range store.GetMessages(mailboxID) {
+					ids = append(ids, msg.ID)
+				}
+
+				if !slices.Equal(ids, expected) {
+					t.Errorf("Messages of mailbox \"%s\" do not match: got %v, expected %v", mailboxID, ids, expected)
+				}
+			}
+		})
+	}
+}
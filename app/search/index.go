@@ -0,0 +1,287 @@
+package search
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"zinktray/app/message"
+)
+
+// field identifies searchable part of a message.
+type field int
+
+const (
+	fieldFrom field = iota
+	fieldTo
+	fieldSubject
+	fieldText
+
+	// fieldCount contains the number of searchable message parts.
+	fieldCount
+)
+
+// document describes searchable contents of individual message.
+type document struct {
+	// fields contains normalized text of every searchable message part. Text field holds contents of all the other
+	// fields as well.
+	fields [fieldCount]string
+
+	// hasAttachment tells whether message has attachments.
+	hasAttachment bool
+
+	// receivedAt contains time message has been received at.
+	receivedAt time.Time
+}
+
+// Index represents inverted index of message contents.
+type Index struct {
+	mutex sync.RWMutex
+
+	// documents maps message ID to its searchable contents.
+	documents map[string]*document
+
+	// postings maps every word of every message part to IDs of messages containing it.
+	postings [fieldCount]map[string]map[string]struct{}
+}
+
+// Add indexes message contents as extracted into message summary.
+//
+// Parts of the message which could not be parsed are not indexed. Adding already indexed message replaces its contents.
+func (index *Index) Add(msg *message.Message) {
+	doc := newDocument(msg)
+
+	index.mutex.Lock()
+
+	defer index.mutex.Unlock()
+
+	index.remove(msg.ID)
+
+	index.documents[msg.ID] = doc
+
+	for f, text := range doc.fields {
+		for _, word := range splitWords(text) {
+			ids, ok := index.postings[f][word]
+
+			if !ok {
+				ids = make(map[string]struct{})
+				index.postings[f][word] = ids
+			}
+
+			ids[msg.ID] = struct{}{}
+		}
+	}
+}
+
+// Remove removes message contents from index.
+func (index *Index) Remove(messageID string) {
+	index.mutex.Lock()
+
+	defer index.mutex.Unlock()
+
+	index.remove(messageID)
+}
+
+// Search returns IDs of indexed messages satisfying query, in no particular order.
+func (index *Index) Search(query *Query) []string {
+	index.mutex.RLock()
+
+	defer index.mutex.RUnlock()
+
+	conditions := [fieldCount][]string{
+		fieldFrom:    normalizeAll(query.From),
+		fieldTo:      normalizeAll(query.To),
+		fieldSubject: normalizeAll(query.Subject),
+		fieldText:    normalizeAll(query.Text),
+	}
+
+	candidates := index.candidates(conditions)
+	result := make([]string, 0, len(candidates))
+
+	for messageID := range candidates {
+		if doc := index.documents[messageID]; doc.match(query, conditions) {
+			result = append(result, messageID)
+		}
+	}
+
+	return result
+}
+
+// candidates returns IDs of messages containing every word of every condition. When conditions contain no words,
+// returns IDs of all indexed messages.
+func (index *Index) candidates(conditions [fieldCount][]string) map[string]struct{} {
+	var result map[string]struct{}
+
+	for f, values := range conditions {
+		for _, value := range values {
+			for _, word := range splitWords(value) {
+				ids := index.postings[f][word]
+
+				if result == nil {
+					result = make(map[string]struct{}, len(ids))
+
+					for messageID := range ids {
+						result[messageID] = struct{}{}
+					}
+				} else {
+					for messageID := range result {
+						if _, ok := ids[messageID]; !ok {
+							delete(result, messageID)
+						}
+					}
+				}
+
+				if len(result) == 0 {
+					return result
+				}
+			}
+		}
+	}
+
+	if result == nil {
+		result = make(map[string]struct{}, len(index.documents))
+
+		for messageID := range index.documents {
+			result[messageID] = struct{}{}
+		}
+	}
+
+	return result
+}
+
+// remove removes message contents from index. Index must be locked for writing.
+func (index *Index) remove(messageID string) {
+	doc, ok := index.documents[messageID]
+
+	if !ok {
+		return
+	}
+
+	for f, text := range doc.fields {
+		for _, word := range splitWords(text) {
+			if ids, ok := index.postings[f][word]; ok {
+				delete(ids, messageID)
+
+				if len(ids) == 0 {
+					delete(index.postings[f], word)
+				}
+			}
+		}
+	}
+
+	delete(index.documents, messageID)
+}
+
+// match tests whether document satisfies query. conditions contain normalized query string conditions.
+func (doc *document) match(query *Query, conditions [fieldCount][]string) bool {
+	if query.HasAttachment && !doc.hasAttachment {
+		return false
+	}
+
+	if !query.Before.IsZero() && !doc.receivedAt.Before(query.Before) {
+		return false
+	}
+
+	if !query.After.IsZero() && doc.receivedAt.Before(query.After) {
+		return false
+	}
+
+	for f, values := range conditions {
+		for _, value := range values {
+			if !strings.Contains(doc.fields[f], value) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// newDocument extracts searchable contents out of message summary, so that message is not parsed again.
+func newDocument(msg *message.Message) *document {
+	doc := &document{
+		receivedAt: msg.ReceivedAt,
+	}
+
+	var from, to, text []string
+
+	if msg.Envelope != nil {
+		from = append(from, msg.Envelope.ReturnPath)
+
+		for _, rcpt := range msg.Envelope.Recipients {
+			to = append(to, rcpt.Address)
+		}
+	}
+
+	summary := msg.GetSummary()
+
+	from = append(from, summary.From...)
+	to = append(to, summary.To...)
+
+	doc.fields[fieldSubject] = normalize(summary.Subject)
+	doc.hasAttachment = summary.HasAttachments
+
+	text = append(text, summary.Text...)
+	text = append(text, summary.AttachmentNames...)
+
+	doc.fields[fieldFrom] = strings.Join(normalizeAll(from), "\n")
+	doc.fields[fieldTo] = strings.Join(normalizeAll(to), "\n")
+	doc.fields[fieldText] = strings.Join(
+		append(
+			[]string{doc.fields[fieldFrom], doc.fields[fieldTo], doc.fields[fieldSubject]},
+			normalizeAll(text)...,
+		),
+		"\n",
+	)
+
+	return doc
+}
+
+// splitWords splits text into unique words, i.e. sequences of letters and digits.
+func splitWords(text string) []string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]struct{}, len(words))
+	result := words[:0]
+
+	for _, word := range words {
+		if _, ok := seen[word]; !ok {
+			seen[word] = struct{}{}
+			result = append(result, word)
+		}
+	}
+
+	return result
+}
+
+// normalize converts text to lowercase and collapses whitespace, so that phrases could be matched regardless of line
+// breaks and markup.
+func normalize(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// normalizeAll normalizes every string.
+func normalizeAll(values []string) []string {
+	result := make([]string, 0, len(values))
+
+	for _, value := range values {
+		result = append(result, normalize(value))
+	}
+
+	return result
+}
+
+// NewIndex creates new empty index structure.
+func NewIndex() *Index {
+	index := &Index{
+		documents: make(map[string]*document),
+	}
+
+	for f := range index.postings {
+		index.postings[f] = make(map[string]map[string]struct{})
+	}
+
+	return index
+}
@@ -0,0 +1,90 @@
+package search
+
+import (
+	"slices"
+	"testing"
+	"time"
+	"zinktray/app/message"
+)
+
+func TestIndexSearch(t *testing.T) {
+	var index = NewIndex()
+
+	var report = message.NewMessage(
+		"From: Alice <alice@example.com>\r\n" +
+			"To: team@example.com\r\n" +
+			"Subject: Weekly =?UTF-8?Q?r=C3=A9port?=\r\n" +
+			"Content-Type: multipart/mixed; boundary=B\r\n" +
+			"\r\n" +
+			"--B\r\n" +
+			"Content-Type: text/html\r\n" +
+			"\r\n" +
+			"<p>Numbers are <b>up</b></p>\r\n" +
+			"--B\r\n" +
+			"Content-Type: application/pdf\r\n" +
+			"Content-Disposition: attachment; filename=summary.pdf\r\n" +
+			"\r\n" +
+			"%PDF\r\n" +
+			"--B--\r\n",
+	)
+	report.ID = "report"
+	report.ReceivedAt = time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	var greeting = message.NewMessage("From: bob@example.org\r\nTo: alice@example.com\r\nSubject: Hello\r\n\r\nHi Alice!")
+	greeting.ID = "greeting"
+	greeting.ReceivedAt = time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)
+	greeting.Envelope = &message.Envelope{
+		ReturnPath: "bounce@example.org",
+		Recipients: []message.Recipient{{Address: "hidden@example.net"}},
+	}
+
+	index.Add(report)
+	index.Add(greeting)
+
+	var cases = []struct {
+		query    string
+		expected []string
+	}{
+		{"", []string{"greeting", "report"}},
+		{"alice", []string{"greeting", "report"}},
+		{"from:alice", []string{"report"}},
+		{"from:bounce@example.org", []string{"greeting"}},
+		{"to:alice@example.com", []string{"greeting"}},
+		{"to:hidden", []string{"greeting"}},
+		{"to:example.net", []string{"greeting"}},
+		{"subject:réport", []string{"report"}},
+		{"subject:RÉPORT numbers", []string{"report"}},
+		{`"numbers are up"`, []string{"report"}},
+		{"summary.pdf", []string{"report"}},
+		{"has:attachment", []string{"report"}},
+		{"after:2024-01-03", []string{"greeting"}},
+		{"before:2024-01-03", []string{"report"}},
+		{"from:alice hello", []string{}},
+		{"nonexistent", []string{}},
+		{"alic", []string{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			query, err := ParseQuery(c.query)
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			result := index.Search(query)
+
+			slices.Sort(result)
+
+			if !slices.Equal(result, c.expected) {
+				t.Fatalf("Search result does not match: got %v, expected %v", result, c.expected)
+			}
+		})
+	}
+
+	index.Remove(report.ID)
+
+	if result := index.Search(&Query{Text: []string{"alice"}}); !slices.Equal(result, []string{"greeting"}) {
+		t.Fatalf("Search result after removal does not match: got %v, expected %v", result, []string{"greeting"})
+	}
+}
@@ -0,0 +1,172 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrUnterminatedQuote is returned upon parsing query with unbalanced double quotes.
+var ErrUnterminatedQuote = errors.New("unterminated quoted string")
+
+// dateLayout contains layout of calendar dates accepted by "before:" and "after:" operators.
+const dateLayout = "2006-01-02"
+
+// Query describes conditions a message is to satisfy. Every condition must be satisfied; empty query is satisfied by
+// any message.
+//
+// String conditions are case-insensitive substrings, and are satisfied when every word of the condition is a word of
+// the respective message part as well.
+type Query struct {
+	// From contains conditions on envelope return path or From header.
+	From []string
+
+	// To contains conditions on envelope recipients or To header.
+	To []string
+
+	// Subject contains conditions on decoded message subject.
+	Subject []string
+
+	// Text contains conditions on any of the above, decoded message body or attachment file names.
+	Text []string
+
+	// HasAttachment tells whether message must have attachments.
+	HasAttachment bool
+
+	// Before contains time message must be received before. Zero time stands for no restriction.
+	Before time.Time
+
+	// After contains time message must be received at or after. Zero time stands for no restriction.
+	After time.Time
+}
+
+// IsEmpty tells whether query is satisfied by any message.
+func (query *Query) IsEmpty() bool {
+	return len(query.From) == 0 &&
+		len(query.To) == 0 &&
+		len(query.Subject) == 0 &&
+		len(query.Text) == 0 &&
+		!query.HasAttachment &&
+		query.Before.IsZero() &&
+		query.After.IsZero()
+}
+
+// ParseQuery parses query string.
+//
+// Query string consists of whitespace-separated terms. Term is either an operator in form "name:value", or free text
+// searched for everywhere. Values containing whitespace are to be enclosed in double quotes. Supported operators are:
+//
+//   - from:address
+//   - to:address
+//   - subject:text
+//   - has:attachment
+//   - before:date and after:date, where date is either a calendar date (2006-01-02), an RFC 3339 timestamp or a Unix
+//     timestamp.
+//
+// Terms with unknown operator names are treated as free text.
+func ParseQuery(text string) (*Query, error) {
+	terms, err := splitTerms(text)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := &Query{}
+
+	for _, term := range terms {
+		name, value, found := strings.Cut(term, ":")
+
+		if !found || value == "" {
+			query.Text = append(query.Text, unquote(term))
+
+			continue
+		}
+
+		value = unquote(value)
+
+		switch strings.ToLower(name) {
+		case "from":
+			query.From = append(query.From, value)
+		case "to":
+			query.To = append(query.To, value)
+		case "subject":
+			query.Subject = append(query.Subject, value)
+		case "has":
+			if !strings.EqualFold(value, "attachment") {
+				return nil, fmt.Errorf("unknown \"has\" condition %q", value)
+			}
+
+			query.HasAttachment = true
+		case "before":
+			if query.Before, err = parseTime(value); err != nil {
+				return nil, fmt.Errorf("invalid \"before\" time %q: %w", value, err)
+			}
+		case "after":
+			if query.After, err = parseTime(value); err != nil {
+				return nil, fmt.Errorf("invalid \"after\" time %q: %w", value, err)
+			}
+		default:
+			query.Text = append(query.Text, unquote(term))
+		}
+	}
+
+	return query, nil
+}
+
+// splitTerms splits query string into whitespace-separated terms. Whitespace inside double quotes does not separate
+// terms, quotes themselves are kept.
+func splitTerms(text string) ([]string, error) {
+	terms := make([]string, 0)
+
+	var term strings.Builder
+	var quoted bool
+
+	for _, r := range text {
+		switch {
+		case r == '"':
+			quoted = !quoted
+
+			term.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if term.Len() > 0 {
+				terms = append(terms, term.String())
+				term.Reset()
+			}
+		default:
+			term.WriteRune(r)
+		}
+	}
+
+	if quoted {
+		return nil, ErrUnterminatedQuote
+	}
+
+	if term.Len() > 0 {
+		terms = append(terms, term.String())
+	}
+
+	return terms, nil
+}
+
+// unquote removes double quotes from term value.
+func unquote(value string) string {
+	return strings.ReplaceAll(value, "\"", "")
+}
+
+// parseTime parses time given either as a calendar date, an RFC 3339 timestamp or a Unix timestamp.
+//
+// Calendar dates stand for midnight UTC.
+func parseTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+
+	if date, err := time.Parse(dateLayout, value); err == nil {
+		return date, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	var cases = []struct {
+		name     string
+		text     string
+		expected Query
+	}{
+		{"empty", "  ", Query{}},
+		{"free text", "hello  world", Query{Text: []string{"hello", "world"}}},
+		{
+			"operators",
+			`FROM:alice@example.com to:bob subject:"weekly report" has:attachment`,
+			Query{
+				From:          []string{"alice@example.com"},
+				To:            []string{"bob"},
+				Subject:       []string{"weekly report"},
+				HasAttachment: true,
+			},
+		},
+		{"quoted free text", `"hello world" again`, Query{Text: []string{"hello world", "again"}}},
+		{"unknown operator", "re:hello from:", Query{Text: []string{"re:hello", "from:"}}},
+		{
+			"dates",
+			"after:2024-01-02 before:1704326400",
+			Query{After: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Before: time.Unix(1704326400, 0)},
+		},
+		{
+			"timestamp",
+			"after:2024-01-02T10:00:00+02:00",
+			Query{After: time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			query, err := ParseQuery(c.text)
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if !query.After.Equal(c.expected.After) || !query.Before.Equal(c.expected.Before) {
+				t.Fatalf("Query times do not match: got %v, expected %v", query, c.expected)
+			}
+
+			query.After, query.Before = c.expected.After, c.expected.Before
+
+			if !reflect.DeepEqual(normalizeQuery(*query), normalizeQuery(c.expected)) {
+				t.Fatalf("Query does not match: got %#v, expected %#v", *query, c.expected)
+			}
+		})
+	}
+}
+
+func TestParseQueryInvalid(t *testing.T) {
+	for _, text := range []string{`subject:"unterminated`, "has:nothing", "before:yesterday", "after:2024-13-01"} {
+		if _, err := ParseQuery(text); err == nil {
+			t.Fatalf("Query %q is expected to be invalid", text)
+		}
+	}
+
+	if _, err := ParseQuery(`"unterminated`); !errors.Is(err, ErrUnterminatedQuote) {
+		t.Fatalf("Error is expected to be \"%s\", got \"%s\"", ErrUnterminatedQuote, err)
+	}
+}
+
+// normalizeQuery replaces nil slices with empty ones, so that queries could be compared.
+func normalizeQuery(query Query) Query {
+	for _, values := range []*[]string{&query.From, &query.To, &query.Subject, &query.Text} {
+		if *values == nil {
+			*values = []string{}
+		}
+	}
+
+	return query
+}
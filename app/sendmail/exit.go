@@ -0,0 +1,60 @@
+package sendmail
+
+import (
+	"errors"
+	"io"
+	"net"
+
+	"github.com/emersion/go-smtp"
+)
+
+// Exit statuses of the command as defined by sysexits.h, so that callers could tell temporary failures, which are
+// worth retrying, from permanent ones.
+const (
+	// ExitUsage is returned for malformed command-line arguments (EX_USAGE).
+	ExitUsage = 64
+
+	// ExitDataErr is returned for malformed message (EX_DATAERR).
+	ExitDataErr = 65
+
+	// ExitUnavailable is returned when SMTP server rejects message permanently (EX_UNAVAILABLE).
+	ExitUnavailable = 69
+
+	// ExitSoftware is returned upon unexpected failure (EX_SOFTWARE).
+	ExitSoftware = 70
+
+	// ExitIOErr is returned when message cannot be read (EX_IOERR).
+	ExitIOErr = 74
+
+	// ExitTempFail is returned when SMTP server is unreachable or rejects message temporarily (EX_TEMPFAIL).
+	ExitTempFail = 75
+
+	// ExitConfig is returned for invalid configuration (EX_CONFIG).
+	ExitConfig = 78
+)
+
+// ExitStatus returns exit status of the command failed with err returned by Send.
+func ExitStatus(err error) int {
+	var smtpErr *smtp.SMTPError
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, ErrNoRecipients):
+		return ExitUsage
+	case errors.Is(err, ErrMalformedMessage):
+		return ExitDataErr
+	case errors.Is(err, errRead):
+		return ExitIOErr
+	case errors.As(err, &smtpErr):
+		if smtpErr.Code/100 == 4 {
+			return ExitTempFail
+		}
+
+		return ExitUnavailable
+	case errors.As(err, &netErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		// Connection has failed or has been dropped by the server.
+		return ExitTempFail
+	}
+
+	return ExitSoftware
+}
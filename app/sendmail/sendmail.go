@@ -0,0 +1,283 @@
+// Package sendmail implements a sendmail-compatible command submitting messages to a running server over SMTP.
+//
+// The command mimics the subset of sendmail interface applications rely on when shelling out to deliver mail:
+// the message is read from standard input, recipients are given as arguments or extracted from message header.
+package sendmail
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"zinktray/app/config"
+
+	"github.com/emersion/go-sasl"
+	"github.com/emersion/go-smtp"
+)
+
+// CommandName contains name the command is invoked by, either as subcommand or via symlink.
+const CommandName = "sendmail"
+
+// ErrNoRecipients is returned when no recipient is given or found in message header.
+var ErrNoRecipients = errors.New("no recipients given")
+
+// ErrMalformedMessage is returned when recipients cannot be extracted from message header.
+var ErrMalformedMessage = errors.New("malformed message")
+
+// errRead is returned when message cannot be read.
+var errRead = errors.New("cannot read message")
+
+// Options contains parsed command-line arguments.
+type Options struct {
+	// Sender contains envelope sender address given by "-f" or "-r" option.
+	Sender string
+
+	// Recipients contains recipient addresses given as arguments.
+	Recipients []string
+
+	// ExtractRecipients tells whether recipients are to be extracted from To, Cc and Bcc headers ("-t" option).
+	ExtractRecipients bool
+
+	// IgnoreDots tells whether a line with a single dot does not terminate input ("-i" or "-oi" option).
+	IgnoreDots bool
+}
+
+// ParseArgs parses sendmail command-line arguments.
+//
+// Options may be grouped (e.g. "-ti") and option arguments may be attached (e.g. "-fsender@example.com"). Options
+// commonly passed by applications which are of no use here (e.g. "-F", "-odi" or "-v") are accepted and ignored.
+func ParseArgs(args []string) (*Options, error) {
+	opts := &Options{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			opts.Recipients = append(opts.Recipients, args[i+1:]...)
+
+			break
+		}
+
+		if len(arg) < 2 || arg[0] != '-' {
+			opts.Recipients = append(opts.Recipients, arg)
+
+			continue
+		}
+
+		for j := 1; j < len(arg); j++ {
+			name := arg[j]
+
+			switch name {
+			case 't':
+				opts.ExtractRecipients = true
+
+				continue
+			case 'i':
+				opts.IgnoreDots = true
+
+				continue
+			case 'G', 'U', 'm', 'n', 'v':
+				continue
+			}
+
+			if !strings.ContainsRune("ABCFLNORVXbfhor", rune(name)) {
+				return nil, fmt.Errorf("unknown option -%c", name)
+			}
+
+			// The rest of the argument or the next argument is option value.
+			value := arg[j+1:]
+
+			if value == "" {
+				if i++; i == len(args) {
+					return nil, fmt.Errorf("option -%c requires an argument", name)
+				}
+
+				value = args[i]
+			}
+
+			switch name {
+			case 'f', 'r':
+				opts.Sender = value
+			case 'o':
+				if value == "i" {
+					opts.IgnoreDots = true
+				}
+			case 'b':
+				if value != "m" {
+					return nil, fmt.Errorf("unsupported mode -b%s", value)
+				}
+			}
+
+			break
+		}
+	}
+
+	return opts, nil
+}
+
+// ReadMessage reads message from r.
+//
+// Unless ignoreDots is set, a line consisting of a single dot terminates the message.
+func ReadMessage(r io.Reader, ignoreDots bool) ([]byte, error) {
+	if ignoreDots {
+		return io.ReadAll(r)
+	}
+
+	var data bytes.Buffer
+
+	reader := bufio.NewReader(r)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+
+		if string(bytes.TrimRight(line, "\r\n")) == "." {
+			break
+		}
+
+		data.Write(line)
+
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return data.Bytes(), nil
+}
+
+// Send reads message from r and submits it to SMTP server.
+//
+// When recipients are extracted from message header, Bcc header is removed before submission. Envelope sender
+// defaults to the address found in From header.
+func Send(cfg config.SendmailConfig, opts *Options, r io.Reader) error {
+	data, err := ReadMessage(r, opts.IgnoreDots)
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", errRead, err)
+	}
+
+	sender := opts.Sender
+	recipients := opts.Recipients
+
+	if opts.ExtractRecipients || sender == "" {
+		header, err := readHeader(data)
+
+		if err != nil && opts.ExtractRecipients {
+			return fmt.Errorf("%w: cannot parse header: %w", ErrMalformedMessage, err)
+		}
+
+		if sender == "" && header != nil {
+			if from, err := header.AddressList("From"); err == nil && len(from) > 0 {
+				sender = from[0].Address
+			}
+		}
+
+		if opts.ExtractRecipients {
+			for _, name := range []string{"To", "Cc", "Bcc"} {
+				addresses, err := header.AddressList(name)
+
+				if err != nil && !errors.Is(err, mail.ErrHeaderNotPresent) {
+					return fmt.Errorf("%w: cannot parse %s header: %w", ErrMalformedMessage, name, err)
+				}
+
+				for _, addr := range addresses {
+					recipients = append(recipients, addr.Address)
+				}
+			}
+
+			data = removeHeader(data, "Bcc")
+		}
+	}
+
+	if recipients = unique(recipients); len(recipients) == 0 {
+		return ErrNoRecipients
+	}
+
+	return submit(cfg, sender, recipients, data)
+}
+
+// submit sends message to SMTP server.
+func submit(cfg config.SendmailConfig, sender string, recipients []string, data []byte) error {
+	client, err := smtp.Dial(cfg.Addr)
+
+	if err != nil {
+		return fmt.Errorf("cannot connect to %s: %w", cfg.Addr, err)
+	}
+
+	defer client.Close()
+
+	if cfg.Username != "" {
+		if err := client.Auth(sasl.NewPlainClient("", cfg.Username, cfg.Password)); err != nil {
+			return fmt.Errorf("cannot authenticate: %w", err)
+		}
+	}
+
+	// Message data is converted to CRLF line endings and dot-stuffed by the client.
+	if err := client.SendMail(sender, recipients, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("cannot submit message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// readHeader parses message header.
+func readHeader(data []byte) (mail.Header, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return msg.Header, nil
+}
+
+// removeHeader removes every field with provided name, along with its continuation lines, from message header.
+func removeHeader(data []byte, name string) []byte {
+	var result bytes.Buffer
+
+	inHeader := true
+	removing := false
+
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if inHeader {
+			switch {
+			case len(bytes.TrimRight(line, "\r\n")) == 0:
+				inHeader = false
+				removing = false
+			case line[0] != ' ' && line[0] != '\t':
+				// Lines starting with whitespace continue the previous field.
+				field, _, _ := bytes.Cut(line, []byte(":"))
+				removing = strings.EqualFold(strings.TrimSpace(string(field)), name)
+			}
+		}
+
+		if !removing {
+			result.Write(line)
+		}
+	}
+
+	return result.Bytes()
+}
+
+// unique returns addresses with duplicates removed, case-insensitive. Order of first occurrences is kept.
+func unique(addresses []string) []string {
+	seen := make(map[string]struct{}, len(addresses))
+	result := make([]string, 0, len(addresses))
+
+	for _, addr := range addresses {
+		key := strings.ToLower(addr)
+
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		seen[key] = struct{}{}
+		result = append(result, addr)
+	}
+
+	return result
+}
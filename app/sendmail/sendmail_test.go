@@ -0,0 +1,194 @@
+package sendmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"zinktray/app/config"
+	"zinktray/app/metrics"
+	"zinktray/app/smtp"
+	"zinktray/app/storage"
+
+	smtp2 "github.com/emersion/go-smtp"
+)
+
+func TestParseArgs(t *testing.T) {
+	var cases = map[string]struct {
+		args     []string
+		expected Options
+	}{
+		"recipients": {
+			[]string{"alice@example.com", "bob@example.com"},
+			Options{Recipients: []string{"alice@example.com", "bob@example.com"}},
+		},
+		"php": {
+			[]string{"-t", "-i"},
+			Options{ExtractRecipients: true, IgnoreDots: true},
+		},
+		"grouped": {
+			[]string{"-ti", "-fsender@example.com"},
+			Options{Sender: "sender@example.com", ExtractRecipients: true, IgnoreDots: true},
+		},
+		"separate values": {
+			[]string{"-oi", "-f", "sender@example.com", "-F", "Sender", "--", "-alice@example.com"},
+			Options{Sender: "sender@example.com", Recipients: []string{"-alice@example.com"}, IgnoreDots: true},
+		},
+		"ignored": {
+			[]string{"-odi", "-oem", "-bm", "-v", "alice@example.com"},
+			Options{Recipients: []string{"alice@example.com"}},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			opts, err := ParseArgs(c.args)
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(*opts, c.expected) {
+				t.Errorf("Options do not match: got %+v, expected %+v", *opts, c.expected)
+			}
+		})
+	}
+
+	for _, args := range [][]string{{"-x"}, {"-f"}, {"-bs"}} {
+		if _, err := ParseArgs(args); err == nil {
+			t.Errorf("Parsing %v is expected to fail", args)
+		}
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	var input = "Subject: Test\n\nfirst\n.\nsecond\n"
+
+	data, err := ReadMessage(strings.NewReader(input), false)
+
+	if err != nil || string(data) != "Subject: Test\n\nfirst\n" {
+		t.Errorf("Single dot line is expected to terminate message: got %q, %v", data, err)
+	}
+
+	data, err = ReadMessage(strings.NewReader(input), true)
+
+	if err != nil || string(data) != input {
+		t.Errorf("Single dot line is expected to be kept: got %q, %v", data, err)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var store = storage.NewMemoryStorage()
+	var cfg = config.Default().Sendmail
+
+	cfg.Addr = "127.0.0.1:2531"
+	cfg.Username = "app"
+
+	var cancel = newServer(t, store, cfg.Addr)
+
+	t.Cleanup(cancel)
+
+	var input = "From: App <app@example.com>\n" +
+		"To: Alice <alice@example.com>\n" +
+		"Bcc: bob@example.com,\n" +
+		"  carol@example.com\n" +
+		"Subject: Report\n" +
+		"\n" +
+		".leading dot\n"
+
+	if err := Send(cfg, &Options{ExtractRecipients: true, IgnoreDots: true}, strings.NewReader(input)); err != nil {
+		t.Fatalf("Cannot send message: %s", err)
+	}
+
+	var messages = store.GetMessages("app")
+
+	if len(messages) != 1 {
+		t.Fatalf("Message is expected to be stored into mailbox named after username: got %d messages", len(messages))
+	}
+
+	var envelope = messages[0].Envelope
+	var recipients []string
+
+	for _, rcpt := range envelope.Recipients {
+		recipients = append(recipients, rcpt.Address)
+	}
+
+	if envelope.ReturnPath != "app@example.com" {
+		t.Errorf("Envelope sender does not match: got \"%s\", expected \"%s\"", envelope.ReturnPath, "app@example.com")
+	}
+
+	if strings.Join(recipients, ",") != "alice@example.com,bob@example.com,carol@example.com" {
+		t.Errorf("Unexpected envelope recipients: %v", recipients)
+	}
+
+	var expected = "From: App <app@example.com>\r\nTo: Alice <alice@example.com>\r\nSubject: Report\r\n\r\n.leading dot\r\n"
+
+	if data := messages[0].GetRawData(); data != expected {
+		t.Errorf("Message contents do not match: got %q, expected %q", data, expected)
+	}
+
+	if err := Send(cfg, &Options{}, strings.NewReader(input)); err != ErrNoRecipients {
+		t.Errorf("Unexpected error: expected \"%s\", got \"%v\"", ErrNoRecipients, err)
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	var cfg = config.Default().Sendmail
+
+	// Nothing listens on the port.
+	cfg.Addr = "127.0.0.1:1"
+
+	var unreachable = Send(cfg, &Options{Recipients: []string{"alice@example.com"}}, strings.NewReader("Subject: Test\n\n"))
+	var malformed = Send(cfg, &Options{ExtractRecipients: true}, strings.NewReader("To: <alice\n\n"))
+
+	var expected = []struct {
+		err    error
+		status int
+	}{
+		{unreachable, ExitTempFail},
+		{malformed, ExitDataErr},
+		{ErrNoRecipients, ExitUsage},
+		{fmt.Errorf("cannot submit message: %w", &smtp2.SMTPError{Code: 451}), ExitTempFail},
+		{fmt.Errorf("cannot submit message: %w", &smtp2.SMTPError{Code: 550}), ExitUnavailable},
+		{errors.New("unexpected"), ExitSoftware},
+	}
+
+	for _, test := range expected {
+		if status := ExitStatus(test.err); status != test.status {
+			t.Errorf("Exit status of \"%v\" does not match: got %d, expected %d", test.err, status, test.status)
+		}
+	}
+}
+
+// newServer starts SMTP server and waits for it to accept connections.
+func newServer(t *testing.T, store storage.Storage, addr string) context.CancelFunc {
+	var ctx, cancel = context.WithCancel(context.Background())
+	var cfg = config.Default().SMTP
+	var wg = &sync.WaitGroup{}
+
+	cfg.Addr = addr
+
+	wg.Add(1)
+
+	go smtp.NewServer(store, cfg, nil, metrics.NewMetrics()).Start(ctx, wg)
+
+	for i := 3; i > 0; i-- {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+
+			return cancel
+		}
+
+		time.Sleep(150 * time.Millisecond)
+	}
+
+	cancel()
+	t.Fatalf("SMTP server is not listening on %s", addr)
+
+	return nil
+}
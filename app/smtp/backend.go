@@ -1,6 +1,7 @@
 package smtp
 
 import (
+	"zinktray/app/metrics"
 	"zinktray/app/storage"
 
 	"github.com/emersion/go-smtp"
@@ -8,12 +9,23 @@ import (
 
 // smtpBackend structure represents an application SMTP backend.
 type smtpBackend struct {
+	// routing contains mailbox routing mode.
+	routing string
+
 	// store provides central message storage.
-	store *storage.Storage
+	store storage.Storage
+
+	// metrics collects counters of mail flowing through the server.
+	metrics *metrics.Metrics
 }
 
 func (b *smtpBackend) NewSession(c *smtp.Conn) (smtp.Session, error) {
+	b.metrics.SmtpConnection()
+
 	return &smtpSession{
-		store: b.store,
+		conn:    c,
+		routing: b.routing,
+		store:   b.store,
+		metrics: b.metrics,
 	}, nil
 }
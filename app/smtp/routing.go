@@ -0,0 +1,42 @@
+package smtp
+
+import (
+	"strings"
+	"zinktray/app/config"
+
+	"github.com/emersion/go-smtp"
+)
+
+// errUnroutableRecipient is returned when no mailbox could be selected for a recipient.
+var errUnroutableRecipient = &smtp.SMTPError{
+	Code:         553,
+	EnhancedCode: smtp.EnhancedCode{5, 1, 3},
+	Message:      "Cannot select mailbox for recipient address",
+}
+
+// routeRecipient selects ID of the mailbox recipient address is to be delivered to.
+//
+// Mailbox IDs are normalized to lowercase. Returns empty string when no mailbox could be selected.
+func routeRecipient(routing string, address string) string {
+	address = strings.ToLower(strings.TrimSpace(address))
+	localPart, domain := address, ""
+
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		localPart, domain = address[:i], address[i+1:]
+	}
+
+	switch routing {
+	case config.RoutingAddress:
+		return address
+	case config.RoutingDomain:
+		return domain
+	case config.RoutingTag:
+		if _, tag, ok := strings.Cut(localPart, "+"); ok && tag != "" {
+			return tag
+		}
+
+		return localPart
+	}
+
+	return ""
+}
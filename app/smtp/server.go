@@ -3,8 +3,12 @@ package smtp
 import (
 	"context"
 	"log"
+	"net"
 	"sync"
 	"time"
+	"zinktray/app/certificate"
+	"zinktray/app/config"
+	"zinktray/app/metrics"
 	"zinktray/app/storage"
 
 	"github.com/emersion/go-smtp"
@@ -14,29 +18,37 @@ import (
 //
 // Handles start and termination of SMTP backend.
 type SmtpServer struct {
+	// certificate contains TLS certificate served by the server.
+	certificate *certificate.Bundle
+
+	// config contains SMTP server configuration.
+	config config.SmtpConfig
+
 	// store provides central message storage.
-	store *storage.Storage
+	store storage.Storage
+
+	// metrics collects counters of mail flowing through the server.
+	metrics *metrics.Metrics
 }
 
 // Start wires-up SMTP server.
 //
+// Starts plaintext listener and, when configured, implicit TLS listener. Both listeners share the same backend.
 // The backend is terminated as soon as ctx is cancelled.
 func (srv *SmtpServer) Start(ctx context.Context, waitGroup *sync.WaitGroup) {
 	defer waitGroup.Done()
 
-	backend := &smtpBackend{
-		store: srv.store,
-	}
+	backend := srv.newBackend()
 
-	server := smtp.NewServer(backend)
+	servers := make([]*smtp.Server, 0, 2)
 
-	server.Addr = ":2525"
-	server.Domain = "zinktray"
-	server.ReadTimeout = 30 * time.Second
-	server.WriteTimeout = 30 * time.Second
-	server.AllowInsecureAuth = true
-	server.MaxMessageBytes = 1024 * 1024
-	server.MaxRecipients = 50
+	server := srv.newServer(backend, srv.config.Addr)
+
+	if srv.config.StartTLS {
+		server.TLSConfig = srv.certificate.TLSConfig()
+	}
+
+	servers = append(servers, server)
 
 	go func() {
 		if err := server.ListenAndServe(); err != nil {
@@ -44,16 +56,93 @@ func (srv *SmtpServer) Start(ctx context.Context, waitGroup *sync.WaitGroup) {
 		}
 	}()
 
+	if srv.config.TLSAddr != "" {
+		tlsServer := srv.newServer(backend, srv.config.TLSAddr)
+		tlsServer.TLSConfig = srv.certificate.TLSConfig()
+
+		servers = append(servers, tlsServer)
+
+		go func() {
+			if err := tlsServer.ListenAndServeTLS(); err != nil {
+				log.Fatalf("SMTPS server failed to start: %s", err)
+			}
+		}()
+	}
+
 	<-ctx.Done()
 
-	if err := server.Close(); err != nil {
-		log.Fatalf("Cannot shutdown SMTP server: %s", err)
+	for _, server := range servers {
+		if err := server.Close(); err != nil {
+			log.Fatalf("Cannot shutdown SMTP server: %s", err)
+		}
+	}
+}
+
+// Serve serves SMTP sessions accepted by listener.
+//
+// Unlike Start, configured listen addresses are ignored, and no implicit TLS listener is started. STARTTLS is
+// advertised when enabled. Blocks until ctx is cancelled, then closes listener along with active connections.
+func (srv *SmtpServer) Serve(ctx context.Context, listener net.Listener) {
+	server := srv.newServer(srv.newBackend(), listener.Addr().String())
+
+	if srv.config.StartTLS {
+		server.TLSConfig = srv.certificate.TLSConfig()
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		server.Close()
+
+		// The server may have been closed before it started accepting connections.
+		listener.Close()
+	}()
+
+	if err := server.Serve(listener); err != nil {
+		log.Printf("SMTP server failed: %s\n", err)
+	}
+}
+
+// newBackend creates SMTP backend storing messages received.
+func (srv *SmtpServer) newBackend() *smtpBackend {
+	return &smtpBackend{
+		routing: srv.config.Routing,
+		store:   srv.store,
+		metrics: srv.metrics,
 	}
 }
 
+// newServer creates SMTP protocol server listening on addr.
+func (srv *SmtpServer) newServer(backend smtp.Backend, addr string) *smtp.Server {
+	server := smtp.NewServer(backend)
+
+	server.Addr = addr
+	server.Domain = srv.config.Domain
+	server.ReadTimeout = time.Duration(srv.config.ReadTimeout)
+	server.WriteTimeout = time.Duration(srv.config.WriteTimeout)
+	server.AllowInsecureAuth = true
+	server.MaxMessageBytes = srv.config.MaxMessageBytes
+	server.MaxRecipients = srv.config.MaxRecipients
+	server.EnableSMTPUTF8 = true
+	server.EnableREQUIRETLS = true
+	server.EnableDSN = true
+
+	return server
+}
+
 // NewServer creates new SMTP server structure.
-func NewServer(storage *storage.Storage) *SmtpServer {
+//
+// certificate is only required when STARTTLS or implicit TLS listener is enabled.
+func NewServer(
+	storage storage.Storage,
+	config config.SmtpConfig,
+	certificate *certificate.Bundle,
+	metrics *metrics.Metrics,
+) *SmtpServer {
 	return &SmtpServer{
-		store: storage,
+		certificate: certificate,
+		config:      config,
+		store:       storage,
+		metrics:     metrics,
 	}
 }
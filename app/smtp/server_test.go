@@ -3,6 +3,9 @@ package smtp
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -11,6 +14,10 @@ import (
 	"sync"
 	"testing"
 	"time"
+	"zinktray/app/certificate"
+	"zinktray/app/config"
+	"zinktray/app/message"
+	"zinktray/app/metrics"
 	"zinktray/app/storage"
 )
 
@@ -42,13 +49,13 @@ type messageSource struct {
 }
 
 func TestSmtp(t *testing.T) {
-	var store = storage.NewStorage()
-	var cancel = newServer(store)
+	var store = storage.NewMemoryStorage()
+	var cancel = newServer(store, config.Default().SMTP, nil)
 
 	t.Cleanup(cancel)
 
 	t.Run("auth", func(t *testing.T) {
-		client := newClient()
+		client := newClient(defaultURL)
 
 		defer client.Close()
 
@@ -85,7 +92,7 @@ func TestSmtp(t *testing.T) {
 				t.Logf("Cannot stat file \"%s\": %s", msg.src, err)
 			}
 
-			var client = newClient()
+			var client = newClient(defaultURL)
 
 			defer client.Close()
 
@@ -133,6 +140,30 @@ func TestSmtp(t *testing.T) {
 		})
 	}
 
+	t.Run("envelope", func(t *testing.T) {
+		for _, msg := range store.GetMessages(mailboxes[0]) {
+			if msg.Envelope == nil {
+				t.Fatalf("Message \"%s\" has no envelope", msg.ID)
+			}
+
+			if msg.Envelope.ReturnPath != "test@localhost" {
+				t.Errorf("Return path does not match: got \"%s\", expected \"%s\"", msg.Envelope.ReturnPath, "test@localhost")
+			}
+
+			if len(msg.Envelope.Recipients) != 1 || msg.Envelope.Recipients[0].Address != "test@localhost" {
+				t.Errorf("Unexpected envelope recipients: %v", msg.Envelope.Recipients)
+			}
+
+			if msg.Envelope.AuthUsername != mailboxes[0] {
+				t.Errorf("Auth username does not match: got \"%s\", expected \"%s\"", msg.Envelope.AuthUsername, mailboxes[0])
+			}
+
+			if msg.Envelope.ClientIP != "127.0.0.1" {
+				t.Errorf("Client IP does not match: got \"%s\", expected \"%s\"", msg.Envelope.ClientIP, "127.0.0.1")
+			}
+		}
+	})
+
 	for mboxID, msgCountExpected := range messagesCount {
 		var msgCount = store.CountMessages(mboxID)
 
@@ -147,9 +178,228 @@ func TestSmtp(t *testing.T) {
 	}
 }
 
-func newServer(storage *storage.Storage) context.CancelFunc {
+func TestSmtpRecipientRouting(t *testing.T) {
+	var recipients = []string{
+		"app+signup@example.com",
+		"other+signup@example.com",
+		"app+reset@example.com",
+		"app@example.com",
+	}
+
+	var routingURLs = map[string]string{
+		config.RoutingAddress: "127.0.0.1:2526",
+		config.RoutingDomain:  "127.0.0.1:2527",
+		config.RoutingTag:     "127.0.0.1:2528",
+	}
+
+	var routingCount = map[string]map[string]int{
+		config.RoutingAddress: {
+			"app+signup@example.com":   1,
+			"other+signup@example.com": 1,
+			"app+reset@example.com":    1,
+			"app@example.com":          1,
+		},
+		config.RoutingDomain: {"example.com": 1},
+		config.RoutingTag:    {"signup": 1, "reset": 1, "app": 1},
+	}
+
+	for routing, messagesCount := range routingCount {
+		t.Run(routing, func(t *testing.T) {
+			var routingURL = routingURLs[routing]
+			var store = storage.NewMemoryStorage()
+			var cfg = config.Default().SMTP
+
+			cfg.Addr = routingURL
+			cfg.Routing = routing
+
+			var cancel = newServer(store, cfg, nil)
+
+			defer cancel()
+
+			var err = sendAnonymous(routingURL, "sender@localhost", recipients)
+
+			if err != nil {
+				t.Fatalf("Cannot send message: %s", err)
+			}
+
+			if mboxCount := store.CountMailboxes(); mboxCount != len(messagesCount) {
+				t.Fatalf("Mailbox count is wrong: got %d, expected %d", mboxCount, len(messagesCount))
+			}
+
+			for mboxID, msgCountExpected := range messagesCount {
+				if msgCount := store.CountMessages(mboxID); msgCount != msgCountExpected {
+					t.Errorf(
+						"Message count is wrong on mailbox \"%s\": got %d, expected %d",
+						mboxID,
+						msgCount,
+						msgCountExpected,
+					)
+				}
+
+				// Every mailbox learns only about recipients routed to it.
+				for _, msg := range store.GetMessages(mboxID) {
+					for _, rcpt := range msg.Envelope.Recipients {
+						if routed := routeRecipient(routing, rcpt.Address); routed != mboxID {
+							t.Errorf("Envelope of mailbox \"%s\" lists recipient of mailbox \"%s\"", mboxID, routed)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSmtpRecipientRoutingFailure(t *testing.T) {
+	var store = &failingStorage{MemoryStorage: storage.NewMemoryStorage(), mailboxID: "reset"}
+	var cfg = config.Default().SMTP
+
+	cfg.Addr = "127.0.0.1:2535"
+	cfg.Routing = config.RoutingTag
+
+	var cancel = newServer(store, cfg, nil)
+
+	defer cancel()
+
+	var err = sendAnonymous(cfg.Addr, "sender@localhost", []string{"app+signup@example.com", "app+reset@example.com"})
+
+	if err == nil {
+		t.Fatal("Sending message is expected to fail")
+	}
+
+	if msgCount := store.CountMessages("signup"); msgCount != 0 {
+		t.Errorf("Message is expected to be stored into no mailbox, got %d messages", msgCount)
+	}
+
+	for _, mailboxID := range []string{"signup", "reset"} {
+		if store.GetMailbox(mailboxID) != nil {
+			t.Errorf("Mailbox \"%s\" created for the message is expected to be removed", mailboxID)
+		}
+	}
+}
+
+// failingStorage represents storage which fails to store messages into mailbox with given ID.
+type failingStorage struct {
+	*storage.MemoryStorage
+
+	mailboxID string
+}
+
+func (store *failingStorage) AddMessage(msg *message.Message, mailboxID string) error {
+	if mailboxID == store.mailboxID {
+		return errors.New("storage failure")
+	}
+
+	return store.MemoryStorage.AddMessage(msg, mailboxID)
+}
+
+func TestSmtpTLS(t *testing.T) {
+	var store = storage.NewMemoryStorage()
+	var cfg = config.Default().SMTP
+
+	cfg.Addr = "127.0.0.1:2529"
+	cfg.StartTLS = true
+	cfg.TLSAddr = "127.0.0.1:2530"
+
+	var bundle, err = certificate.Generate([]string{"localhost", "127.0.0.1"})
+
+	if err != nil {
+		t.Fatalf("Cannot generate certificate: %s", err)
+	}
+
+	var cancel = newServer(store, cfg, bundle)
+
+	t.Cleanup(cancel)
+
+	var roots = x509.NewCertPool()
+
+	if !roots.AppendCertsFromPEM(bundle.TrustPEM) {
+		t.Fatal("Cannot parse trusted certificate")
+	}
+
+	var tlsConfig = &tls.Config{RootCAs: roots, ServerName: "127.0.0.1"}
+
+	t.Run("starttls", func(t *testing.T) {
+		var client = newClient(cfg.Addr)
+
+		defer client.Close()
+
+		if err := client.StartTLS(tlsConfig); err != nil {
+			t.Fatalf("Cannot start TLS: %s", err)
+		}
+
+		if err := client.Auth(smtp.PlainAuth("", mailboxes[0], "", "127.0.0.1")); err != nil {
+			t.Fatalf("Cannot authenticate: %s", err)
+		}
+
+		if err := sendMessage(client, "test@localhost", []string{"test@localhost"}); err != nil {
+			t.Fatalf("Cannot send message: %s", err)
+		}
+	})
+
+	t.Run("implicit", func(t *testing.T) {
+		var client = newTLSClient(cfg.TLSAddr, tlsConfig)
+
+		defer client.Close()
+
+		if err := client.Auth(smtp.PlainAuth("", mailboxes[0], "", "127.0.0.1")); err != nil {
+			t.Fatalf("Cannot authenticate: %s", err)
+		}
+
+		if err := sendMessage(client, "test@localhost", []string{"test@localhost"}); err != nil {
+			t.Fatalf("Cannot send message: %s", err)
+		}
+	})
+
+	var msgList = store.GetMessages(mailboxes[0])
+
+	if len(msgList) != 2 {
+		t.Fatalf("Message count is wrong: got %d, expected %d", len(msgList), 2)
+	}
+
+	for _, msg := range msgList {
+		if msg.Envelope == nil || !msg.Envelope.TLS {
+			t.Errorf("Message \"%s\" is expected to be transmitted over TLS", msg.ID)
+		}
+	}
+}
+
+// sendAnonymous sends a simple message without authentication.
+func sendAnonymous(addr string, from string, to []string) error {
+	var client = newClient(addr)
+
+	defer client.Close()
+
+	return sendMessage(client, from, to)
+}
+
+// sendMessage sends a simple message over established client connection.
+func sendMessage(client *smtp.Client, from string, to []string) error {
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = w.Write([]byte("Subject: Test\r\n\r\nTest\r\n")); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+func newServer(storage storage.Storage, cfg config.SmtpConfig, bundle *certificate.Bundle) context.CancelFunc {
 	var ctx, cancel = context.WithCancel(context.Background())
-	var server = NewServer(storage)
+	var server = NewServer(storage, cfg, bundle, metrics.NewMetrics())
 	var wg = &sync.WaitGroup{}
 
 	wg.Add(1)
@@ -161,17 +411,37 @@ func newServer(storage *storage.Storage) context.CancelFunc {
 	return cancel
 }
 
-func newClient() *smtp.Client {
+func newTLSClient(addr string, tlsConfig *tls.Config) *smtp.Client {
+	var conn *tls.Conn
+	var client *smtp.Client
+	var err error
+
+	for i := 3; i > 0; i-- {
+		if conn, err = tls.Dial("tcp", addr, tlsConfig); err == nil {
+			if client, err = smtp.NewClient(conn, tlsConfig.ServerName); err == nil {
+				return client
+			}
+
+			conn.Close()
+		}
+
+		time.Sleep(150 * time.Millisecond)
+	}
+
+	panic(fmt.Sprintf("Cannot dial %s: %s", addr, err))
+}
+
+func newClient(addr string) *smtp.Client {
 	var client *smtp.Client
 	var err error
 
 	for i := 3; i > 0; i-- {
-		if client, err = smtp.Dial(defaultURL); err == nil {
+		if client, err = smtp.Dial(addr); err == nil {
 			return client
 		}
 
 		time.Sleep(150 * time.Microsecond)
 	}
 
-	panic(fmt.Sprintf("Cannot dial %s: %s", defaultURL, err))
+	panic(fmt.Sprintf("Cannot dial %s: %s", addr, err))
 }
@@ -4,7 +4,11 @@ import (
 	"errors"
 	"io"
 	"log"
+	"net"
+	"slices"
+	"zinktray/app/config"
 	"zinktray/app/message"
+	"zinktray/app/metrics"
 	"zinktray/app/storage"
 
 	"github.com/emersion/go-sasl"
@@ -17,46 +21,177 @@ var errEmptyUsername = errors.New("username is mandatory")
 
 // smtpSession represents information on individual SMTP session.
 type smtpSession struct {
+	// conn contains underlying SMTP connection.
+	conn *smtp.Conn
+
+	// routing contains mailbox routing mode.
+	routing string
+
 	// store provides central message storage.
-	store *storage.Storage
+	store storage.Storage
 
-	// mailboxID contains ID of the mailbox in use.
+	// mailboxID contains ID of the mailbox selected by authentication.
 	mailboxID string
+
+	// recipientMailboxIDs contains IDs of the mailboxes selected by recipients of the message being currently processed.
+	recipientMailboxIDs []string
+
+	// username contains username the client has authenticated with.
+	username string
+
+	// envelope contains envelope of the message being currently processed.
+	envelope *message.Envelope
+
+	// metrics collects counters of mail flowing through the server.
+	metrics *metrics.Metrics
 }
 
-func (session *smtpSession) Mail(_ string, _ *smtp.MailOptions) error {
-	if session.mailboxID == "" {
+func (session *smtpSession) Mail(from string, opts *smtp.MailOptions) error {
+	if session.routing == config.RoutingAuth && session.mailboxID == "" {
+		session.metrics.MessageRejected("auth_required")
+
 		return errAuthenticationRequired
 	}
 
-	// Allow any "FROM" address (even malformed) since it is not used in any way.
+	// Allow any "FROM" address (even malformed) since it is only recorded.
+	_, isTLS := session.conn.TLSConnectionState()
+
+	session.envelope = &message.Envelope{
+		ReturnPath:   from,
+		Recipients:   make([]message.Recipient, 0, 1),
+		Helo:         session.conn.Hostname(),
+		ClientIP:     clientIP(session.conn.Conn().RemoteAddr()),
+		TLS:          isTLS,
+		AuthUsername: session.username,
+	}
+
+	if opts != nil {
+		session.envelope.Size = opts.Size
+		session.envelope.Body = string(opts.Body)
+		session.envelope.SmtpUtf8 = opts.UTF8
+		session.envelope.RequireTLS = opts.RequireTLS
+		session.envelope.DsnReturn = string(opts.Return)
+		session.envelope.DsnEnvelopeID = opts.EnvelopeID
+		session.envelope.MailAuth = opts.Auth
+	}
+
 	return nil
 }
 
-func (session *smtpSession) Rcpt(_ string, _ *smtp.RcptOptions) error {
-	// Allow any "RCPT" address (even malformed) since it is not used in any way.
+func (session *smtpSession) Rcpt(to string, opts *smtp.RcptOptions) error {
+	// Allow any "RCPT" address (even malformed) unless it is used for routing.
+	if session.routing != config.RoutingAuth {
+		mailboxID := routeRecipient(session.routing, to)
+
+		if mailboxID == "" {
+			session.metrics.RecipientRejected("unroutable")
+
+			return errUnroutableRecipient
+		}
+
+		if !slices.Contains(session.recipientMailboxIDs, mailboxID) {
+			session.recipientMailboxIDs = append(session.recipientMailboxIDs, mailboxID)
+		}
+	}
+
+	recipient := message.Recipient{
+		Address: to,
+	}
+
+	if opts != nil {
+		for _, notify := range opts.Notify {
+			recipient.DsnNotify = append(recipient.DsnNotify, string(notify))
+		}
+
+		recipient.DsnOriginalRecipient = opts.OriginalRecipient
+		recipient.DsnOriginalRecipientType = string(opts.OriginalRecipientType)
+	}
+
+	session.envelope.Recipients = append(session.envelope.Recipients, recipient)
+
 	return nil
 }
 
 func (session *smtpSession) Data(reader io.Reader) error {
 	if buffer, err := io.ReadAll(reader); err != nil {
+		if errors.Is(err, smtp.ErrDataTooLarge) {
+			session.metrics.MessageRejected("too_large")
+		} else {
+			session.metrics.MessageRejected("read_error")
+		}
+
 		return err
 	} else {
-		mbox := session.store.AddMailbox(session.mailboxID)
-		msg := message.NewMessage(string(buffer))
+		var stored []*message.Message
+		var created []string
+
+		for _, mailboxID := range session.targetMailboxIDs() {
+			if session.store.GetMailbox(mailboxID) == nil {
+				created = append(created, mailboxID)
+			}
 
-		if err := session.store.AddMessage(msg, mbox.ID); err != nil {
-			log.Printf("Cannot store message: %s", err)
+			msg, err := storage.Deliver(session.store, mailboxID, string(buffer), session.mailboxEnvelope(mailboxID))
 
-			return errInternal
+			if err != nil {
+				log.Printf("Cannot store message: %s", err)
+
+				// Message is either stored into every mailbox or into none, so that client retry does not duplicate it.
+				for _, msg := range stored {
+					session.store.DeleteMessage(msg.ID)
+				}
+
+				// Mailboxes created for the message are removed as well, unless other messages have been stored meanwhile.
+				for _, mailboxID := range created {
+					if session.store.CountMessages(mailboxID) == 0 {
+						session.store.DeleteMailbox(mailboxID)
+					}
+				}
+
+				session.metrics.MessageRejected("storage_error")
+
+				return errInternal
+			}
+
+			stored = append(stored, msg)
 		}
+
+		session.metrics.MessageAccepted(len(buffer))
 	}
 
 	return nil
 }
 
+// mailboxEnvelope returns copy of current message envelope for mailbox with provided ID, listing only recipients
+// routed to that mailbox, so that mailboxes do not learn about each other's recipients.
+func (session *smtpSession) mailboxEnvelope(mailboxID string) *message.Envelope {
+	if session.routing == config.RoutingAuth {
+		return session.envelope
+	}
+
+	envelope := *session.envelope
+	envelope.Recipients = make([]message.Recipient, 0, 1)
+
+	for _, recipient := range session.envelope.Recipients {
+		if routeRecipient(session.routing, recipient.Address) == mailboxID {
+			envelope.Recipients = append(envelope.Recipients, recipient)
+		}
+	}
+
+	return &envelope
+}
+
+// targetMailboxIDs returns IDs of the mailboxes current message is to be stored into.
+func (session *smtpSession) targetMailboxIDs() []string {
+	if session.routing == config.RoutingAuth {
+		return []string{session.mailboxID}
+	}
+
+	return session.recipientMailboxIDs
+}
+
 func (session *smtpSession) Reset() {
-	// Nothing to reset.
+	session.envelope = nil
+	session.recipientMailboxIDs = nil
 }
 
 func (session *smtpSession) Logout() error {
@@ -67,9 +202,20 @@ func (session *smtpSession) Logout() error {
 func (session *smtpSession) Auth(mech string) (sasl.Server, error) {
 	var authenticator = func(identity string, username string, password string) error {
 		if username == "" {
+			session.metrics.SmtpAuth(false)
+
 			return errEmptyUsername
 		}
 
+		session.metrics.SmtpAuth(true)
+
+		session.username = username
+
+		if session.routing != config.RoutingAuth {
+			// Mailbox is selected by recipients, username is only recorded.
+			return nil
+		}
+
 		mbox := session.store.GetMailbox(username)
 		if mbox == nil {
 			mbox = session.store.AddMailbox(username)
@@ -86,3 +232,16 @@ func (session *smtpSession) Auth(mech string) (sasl.Server, error) {
 func (session *smtpSession) AuthMechanisms() []string {
 	return []string{sasl.Plain}
 }
+
+// clientIP extracts IP address out of client network address.
+func clientIP(addr net.Addr) string {
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		return tcpAddr.IP.String()
+	}
+
+	if addr == nil {
+		return ""
+	}
+
+	return addr.String()
+}
@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"zinktray/app/message"
+)
+
+// Deliver stores new message out of raw contents into mailbox with provided ID, registering the mailbox if necessary.
+//
+// envelope may be nil for messages not delivered via SMTP. Returns stored message.
+func Deliver(store Storage, mailboxID string, rawData string, envelope *message.Envelope) (*message.Message, error) {
+	mbox := store.AddMailbox(mailboxID)
+	msg := message.NewMessage(rawData)
+	msg.Envelope = envelope
+
+	if err := store.AddMessage(msg, mbox.ID); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"testing"
+	"zinktray/app/message"
+)
+
+func TestDeliver(t *testing.T) {
+	var storage = NewMemoryStorage()
+	var envelope = &message.Envelope{ReturnPath: "sender@example.com"}
+
+	msg, err := Deliver(storage, "test-mailbox", "Subject: Test\r\n\r\nBody\r\n", envelope)
+
+	if err != nil {
+		t.Fatalf("Cannot deliver message: %s", err)
+	}
+
+	if storage.GetMailbox("test-mailbox") == nil {
+		t.Fatal("Mailbox is expected to be registered upon delivery")
+	}
+
+	if stored := storage.GetMessage(msg.ID); stored == nil || stored.Envelope != envelope {
+		t.Fatal("Delivered message is expected to be stored along with envelope")
+	}
+
+	if msg.GetRawData() != "Subject: Test\r\n\r\nBody\r\n" {
+		t.Errorf("Message contents do not match: got %q", msg.GetRawData())
+	}
+}
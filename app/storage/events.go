@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"log"
+	"sync"
+	"zinktray/app/message"
+)
+
+// EventType describes kind of storage change.
+type EventType string
+
+const (
+	// EventMailboxAdded is published upon registering new mailbox.
+	EventMailboxAdded EventType = "mailbox.added"
+
+	// EventMailboxDeleted is published upon deleting mailbox, after events on deletion of its messages.
+	EventMailboxDeleted EventType = "mailbox.deleted"
+
+	// EventMessageAdded is published upon storing new message.
+	EventMessageAdded EventType = "message.added"
+
+	// EventMessageDeleted is published upon deleting stored message.
+	EventMessageDeleted EventType = "message.deleted"
+)
+
+// Event structure represents individual storage change.
+type Event struct {
+	// Type contains kind of the change.
+	Type EventType
+
+	// MailboxID contains ID of the mailbox affected.
+	MailboxID string
+
+	// MessageID contains ID of the message affected. Empty for mailbox events.
+	MessageID string
+
+	// Message contains the message stored. nil for any event other than EventMessageAdded.
+	Message *message.Message
+}
+
+// EventBus delivers storage events to subscribers.
+//
+// Events are delivered without blocking the storage: when subscriber buffer is full the event is dropped for that
+// subscriber, and the subscriber is notified of the drop.
+type EventBus struct {
+	mutex sync.RWMutex
+
+	// subscriptions contains all active subscriptions.
+	subscriptions map[*Subscription]struct{}
+}
+
+// Subscription structure represents individual event subscription.
+type Subscription struct {
+	// Events delivers published events. The channel is closed upon closing subscription.
+	Events <-chan Event
+
+	// Dropped receives a value once events have been dropped since the previous value has been received, so that
+	// subscriber could look through the storage instead.
+	Dropped <-chan struct{}
+
+	// events contains sending side of Events channel.
+	events chan Event
+
+	// dropped contains sending side of Dropped channel.
+	dropped chan struct{}
+
+	// bus contains event bus subscription belongs to.
+	bus *EventBus
+}
+
+// Subscribe creates new subscription with event buffer of provided size.
+//
+// Subscription must be closed as soon as it is not needed anymore.
+func (bus *EventBus) Subscribe(bufferSize int) *Subscription {
+	events := make(chan Event, bufferSize)
+	dropped := make(chan struct{}, 1)
+
+	subscription := &Subscription{
+		Events:  events,
+		Dropped: dropped,
+		events:  events,
+		dropped: dropped,
+		bus:     bus,
+	}
+
+	bus.mutex.Lock()
+
+	defer bus.mutex.Unlock()
+
+	bus.subscriptions[subscription] = struct{}{}
+
+	return subscription
+}
+
+// Close cancels subscription and closes its event channel.
+//
+// Closing subscription more than once has no effect.
+func (subscription *Subscription) Close() {
+	bus := subscription.bus
+
+	bus.mutex.Lock()
+
+	defer bus.mutex.Unlock()
+
+	if _, ok := bus.subscriptions[subscription]; ok {
+		delete(bus.subscriptions, subscription)
+		close(subscription.events)
+	}
+}
+
+// publish delivers event to every active subscription.
+func (bus *EventBus) publish(event Event) {
+	bus.mutex.RLock()
+
+	defer bus.mutex.RUnlock()
+
+	for subscription := range bus.subscriptions {
+		select {
+		case subscription.events <- event:
+		default:
+			log.Printf("Event subscriber is too slow, dropping \"%s\" event\n", event.Type)
+
+			select {
+			case subscription.dropped <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// NewEventBus creates new event bus structure.
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"testing"
+	"time"
+	"zinktray/app/message"
+)
+
+func TestEvents(t *testing.T) {
+	var storage = NewMemoryStorage()
+	var subscription = storage.Subscribe(16)
+
+	var mboxID = "mailbox_1"
+	var msgID1 = "message_1"
+	var msgID2 = "message_2"
+
+	storage.AddMailbox(mboxID)
+	storage.AddMailbox(mboxID)
+
+	_ = storage.AddMessage(&message.Message{ID: msgID1, ReceivedAt: time.Time{}}, mboxID)
+	_ = storage.AddMessage(&message.Message{ID: msgID2, ReceivedAt: time.Time{}}, mboxID)
+
+	storage.DeleteMessage(msgID1)
+	storage.DeleteMailbox(mboxID)
+
+	subscription.Close()
+	subscription.Close()
+
+	var eventsExpected = []Event{
+		{Type: EventMailboxAdded, MailboxID: mboxID},
+		{Type: EventMessageAdded, MailboxID: mboxID, MessageID: msgID1},
+		{Type: EventMessageAdded, MailboxID: mboxID, MessageID: msgID2},
+		{Type: EventMessageDeleted, MailboxID: mboxID, MessageID: msgID1},
+		{Type: EventMessageDeleted, MailboxID: mboxID, MessageID: msgID2},
+		{Type: EventMailboxDeleted, MailboxID: mboxID},
+	}
+
+	var i int
+
+	for event := range subscription.Events {
+		if i >= len(eventsExpected) {
+			t.Fatalf("Unexpected event: %+v", event)
+		}
+
+		var expected = eventsExpected[i]
+
+		if event.Type != expected.Type || event.MailboxID != expected.MailboxID || event.MessageID != expected.MessageID {
+			t.Errorf("Event does not match at index %d: got %+v, expected %+v", i, event, expected)
+		}
+
+		if event.Type == EventMessageAdded && (event.Message == nil || event.Message.ID != event.MessageID) {
+			t.Errorf("Event at index %d is expected to carry added message", i)
+		}
+
+		i++
+	}
+
+	if i != len(eventsExpected) {
+		t.Fatalf("Event count does not match: got %d, expected %d", i, len(eventsExpected))
+	}
+}
+
+func TestEventsSlowSubscriber(t *testing.T) {
+	var storage = NewMemoryStorage()
+	var subscription = storage.Subscribe(1)
+
+	defer subscription.Close()
+
+	storage.AddMailbox("mailbox_1")
+	storage.AddMailbox("mailbox_2")
+
+	if event := <-subscription.Events; event.MailboxID != "mailbox_1" {
+		t.Fatalf("Unexpected event: %+v", event)
+	}
+
+	select {
+	case event := <-subscription.Events:
+		t.Fatalf("Event is expected to be dropped, got %+v", event)
+	default:
+	}
+
+	select {
+	case <-subscription.Dropped:
+	default:
+		t.Fatal("Subscriber is expected to be notified of dropped event")
+	}
+}
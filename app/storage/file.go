@@ -0,0 +1,295 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"zinktray/app/mailbox"
+	"zinktray/app/message"
+)
+
+// mailboxIndexFile contains name of the file listing registered mailboxes.
+const mailboxIndexFile = "mailboxes.json"
+
+// messagesDir contains name of the directory holding message files.
+const messagesDir = "messages"
+
+// messageFileExt contains extension of message files.
+const messageFileExt = ".json"
+
+// FileStorage represents central storage keeping everything mail in files inside a directory.
+//
+// Directory contains an index of registered mailboxes and a file per stored message. Every change is written through
+// to disk, while all reads are served from in-memory copy loaded upon creation.
+type FileStorage struct {
+	*MemoryStorage
+
+	// indexMutex serializes mailbox index updates.
+	indexMutex sync.Mutex
+
+	// addMutex serializes message additions, so that no two messages with the same ID are written.
+	addMutex sync.Mutex
+
+	// path contains path to storage directory.
+	path string
+}
+
+// messageRecord describes message file contents.
+type messageRecord struct {
+	ID         string            `json:"id"`
+	MailboxID  string            `json:"mailboxId"`
+	ReceivedAt time.Time         `json:"receivedAt"`
+	Envelope   *message.Envelope `json:"envelope"`
+	RawData    string            `json:"rawData"`
+}
+
+// AddMailbox registers mailbox ID and returns corresponding mailbox.
+//
+// When mailbox ID is already registered returns that mailbox.
+func (storage *FileStorage) AddMailbox(mailboxID string) *mailbox.Mailbox {
+	if mbx := storage.MemoryStorage.GetMailbox(mailboxID); mbx != nil {
+		return mbx
+	}
+
+	mbx := storage.MemoryStorage.AddMailbox(mailboxID)
+
+	storage.writeMailboxIndex()
+
+	return mbx
+}
+
+// AddMessage stores new message and binds it to mailbox with provided ID.
+//
+// Message file is written before the message becomes visible to readers and subscribers. Returns ErrDuplicate error
+// upon adding message with an ID that is already present in the storage in any mailbox. Returns an error when message
+// file cannot be written.
+func (storage *FileStorage) AddMessage(msg *message.Message, mailboxID string) error {
+	storage.addMutex.Lock()
+
+	defer storage.addMutex.Unlock()
+
+	// Message file of a duplicate would replace the file of the message already stored.
+	if err := storage.MemoryStorage.checkMessage(msg.ID, mailboxID); err != nil {
+		return err
+	}
+
+	record := &messageRecord{
+		ID:         msg.ID,
+		MailboxID:  mailboxID,
+		ReceivedAt: msg.ReceivedAt,
+		Envelope:   msg.Envelope,
+		RawData:    msg.GetRawData(),
+	}
+
+	if err := storage.writeMessage(record); err != nil {
+		return err
+	}
+
+	if err := storage.MemoryStorage.AddMessage(msg, mailboxID); err != nil {
+		storage.removeMessage(msg.ID)
+
+		return err
+	}
+
+	return nil
+}
+
+// DeleteMailbox deletes registered mailbox along with all its messages.
+func (storage *FileStorage) DeleteMailbox(mailboxID string) {
+	for _, messageID := range storage.MemoryStorage.deleteMailbox(mailboxID) {
+		storage.removeMessage(messageID)
+	}
+
+	storage.writeMailboxIndex()
+}
+
+// DeleteMessage deletes stored message.
+func (storage *FileStorage) DeleteMessage(messageID string) {
+	storage.MemoryStorage.DeleteMessage(messageID)
+
+	storage.removeMessage(messageID)
+}
+
+// load reads storage directory contents into memory.
+func (storage *FileStorage) load() error {
+	if data, err := os.ReadFile(filepath.Join(storage.path, mailboxIndexFile)); err == nil {
+		var mailboxIDs []string
+
+		if err := json.Unmarshal(data, &mailboxIDs); err != nil {
+			return fmt.Errorf("cannot decode mailbox index: %w", err)
+		}
+
+		for _, mailboxID := range mailboxIDs {
+			storage.MemoryStorage.AddMailbox(mailboxID)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("cannot read mailbox index: %w", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(storage.path, messagesDir))
+
+	if err != nil {
+		return fmt.Errorf("cannot read messages directory: %w", err)
+	}
+
+	records := make([]*messageRecord, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), messageFileExt) {
+			continue
+		}
+
+		record, err := storage.readMessage(entry.Name())
+
+		if err != nil {
+			log.Printf("Cannot load message file \"%s\": %s", entry.Name(), err)
+
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	// Messages are pushed to the front of the lists, so the oldest ones go first.
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].ReceivedAt.Before(records[j].ReceivedAt)
+	})
+
+	for _, record := range records {
+		msg := message.NewMessage(record.RawData)
+		msg.ID = record.ID
+		msg.ReceivedAt = record.ReceivedAt
+		msg.Envelope = record.Envelope
+
+		storage.MemoryStorage.AddMailbox(record.MailboxID)
+
+		if err := storage.MemoryStorage.AddMessage(msg, record.MailboxID); err != nil {
+			log.Printf("Cannot load message \"%s\": %s", record.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// readMessage reads message file.
+func (storage *FileStorage) readMessage(name string) (*messageRecord, error) {
+	data, err := os.ReadFile(filepath.Join(storage.path, messagesDir, name))
+
+	if err != nil {
+		return nil, err
+	}
+
+	record := &messageRecord{}
+
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// writeMessage writes message file.
+func (storage *FileStorage) writeMessage(record *messageRecord) error {
+	data, err := json.Marshal(record)
+
+	if err != nil {
+		return fmt.Errorf("cannot encode message: %w", err)
+	}
+
+	if err := writeFileAtomic(storage.messagePath(record.ID), data); err != nil {
+		return fmt.Errorf("cannot write message file: %w", err)
+	}
+
+	return nil
+}
+
+// removeMessage removes message file.
+func (storage *FileStorage) removeMessage(messageID string) {
+	if err := os.Remove(storage.messagePath(messageID)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Cannot remove message file: %s", err)
+	}
+}
+
+// writeMailboxIndex writes the list of currently registered mailboxes.
+func (storage *FileStorage) writeMailboxIndex() {
+	storage.indexMutex.Lock()
+
+	defer storage.indexMutex.Unlock()
+
+	mailboxes := storage.MemoryStorage.GetMailboxes()
+	mailboxIDs := make([]string, 0, len(mailboxes))
+
+	for _, mbx := range mailboxes {
+		mailboxIDs = append(mailboxIDs, mbx.ID)
+	}
+
+	data, err := json.Marshal(mailboxIDs)
+
+	if err != nil {
+		log.Printf("Cannot encode mailbox index: %s", err)
+
+		return
+	}
+
+	if err := writeFileAtomic(filepath.Join(storage.path, mailboxIndexFile), data); err != nil {
+		log.Printf("Cannot write mailbox index: %s", err)
+	}
+}
+
+// messagePath returns path to the file of message with provided ID.
+func (storage *FileStorage) messagePath(messageID string) string {
+	return filepath.Join(storage.path, messagesDir, url.PathEscape(messageID)+messageFileExt)
+}
+
+// writeFileAtomic writes data to a temporary file and then renames it, so that readers never see partial contents.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// NewFileStorage creates file storage structure keeping everything in directory at path.
+//
+// Directory is created when missing. Previously stored mailboxes and messages are loaded.
+func NewFileStorage(path string) (*FileStorage, error) {
+	if err := os.MkdirAll(filepath.Join(path, messagesDir), 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create storage directory: %w", err)
+	}
+
+	storage := &FileStorage{
+		MemoryStorage: NewMemoryStorage(),
+		path:          path,
+	}
+
+	if err := storage.load(); err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
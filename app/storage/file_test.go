@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"zinktray/app/message"
+)
+
+func TestFileStoragePersistence(t *testing.T) {
+	var path = t.TempDir()
+
+	var storage, err = NewFileStorage(path)
+
+	if err != nil {
+		t.Fatalf("Cannot create file storage: %s", err)
+	}
+
+	var mboxID1 = "mailbox_1"
+	var mboxID2 = "mailbox/2"
+	var mboxID3 = "mailbox_3"
+
+	storage.AddMailbox(mboxID1)
+	storage.AddMailbox(mboxID2)
+	storage.AddMailbox(mboxID3)
+
+	var msg1 = message.NewMessage("Subject: First\r\n\r\nFirst")
+	var msg2 = message.NewMessage("Subject: Second\r\n\r\nSecond")
+	var msg3 = message.NewMessage("Subject: Third\r\n\r\nThird")
+	var msg4 = message.NewMessage("Subject: Fourth\r\n\r\nFourth")
+
+	msg2.ReceivedAt = msg1.ReceivedAt.Add(1)
+	msg2.Envelope = &message.Envelope{ReturnPath: "sender@localhost"}
+
+	for _, msg := range []*message.Message{msg1, msg2, msg3} {
+		if err := storage.AddMessage(msg, mboxID1); err != nil {
+			t.Fatalf("Unexpected error upon adding message: %s", err)
+		}
+	}
+
+	if err := storage.AddMessage(msg4, mboxID2); err != nil {
+		t.Fatalf("Unexpected error upon adding message: %s", err)
+	}
+
+	storage.DeleteMessage(msg3.ID)
+	storage.DeleteMailbox(mboxID3)
+
+	storage, err = NewFileStorage(path)
+
+	if err != nil {
+		t.Fatalf("Cannot reopen file storage: %s", err)
+	}
+
+	var mboxes = storage.GetMailboxes()
+
+	if len(mboxes) != 2 || mboxes[0].ID != mboxID1 || mboxes[1].ID != mboxID2 {
+		t.Fatalf("Mailboxes are not restored: got %v", mboxes)
+	}
+
+	var msgList = storage.GetMessages(mboxID1)
+
+	if len(msgList) != 2 {
+		t.Fatalf("Message count does not match: got %d, expected %d", len(msgList), 2)
+	}
+
+	for i, msgExpected := range []*message.Message{msg2, msg1} {
+		var msg = msgList[i]
+
+		if msg.ID != msgExpected.ID {
+			t.Fatalf("Message ID does not match at index %d: got \"%s\", expected \"%s\"", i, msg.ID, msgExpected.ID)
+		}
+
+		if !msg.ReceivedAt.Equal(msgExpected.ReceivedAt) {
+			t.Errorf("Receive time does not match: got %s, expected %s", msg.ReceivedAt, msgExpected.ReceivedAt)
+		}
+
+		if msg.GetRawData() != msgExpected.GetRawData() {
+			t.Errorf("Message contents do not match: got \"%s\", expected \"%s\"", msg.GetRawData(), msgExpected.GetRawData())
+		}
+	}
+
+	if msgList[0].Envelope == nil || msgList[0].Envelope.ReturnPath != "sender@localhost" {
+		t.Errorf("Message envelope is not restored: got %v", msgList[0].Envelope)
+	}
+
+	if msgCount := storage.CountMessages(mboxID2); msgCount != 1 {
+		t.Errorf("Message count does not match: got %d, expected %d", msgCount, 1)
+	}
+}
+
+func TestFileStorageDeleteMailbox(t *testing.T) {
+	var path = t.TempDir()
+
+	var storage, err = NewFileStorage(path)
+
+	if err != nil {
+		t.Fatalf("Cannot create file storage: %s", err)
+	}
+
+	var mboxID1 = "mailbox_1"
+	var mboxID2 = "mailbox_2"
+
+	storage.AddMailbox(mboxID1)
+	storage.AddMailbox(mboxID2)
+
+	var msg1 = message.NewMessage("Subject: First\r\n\r\nFirst")
+	var msg2 = message.NewMessage("Subject: Second\r\n\r\nSecond")
+	var msg3 = message.NewMessage("Subject: Third\r\n\r\nThird")
+
+	for _, msg := range []*message.Message{msg1, msg2} {
+		if err := storage.AddMessage(msg, mboxID1); err != nil {
+			t.Fatalf("Unexpected error upon adding message: %s", err)
+		}
+	}
+
+	if err := storage.AddMessage(msg3, mboxID2); err != nil {
+		t.Fatalf("Unexpected error upon adding message: %s", err)
+	}
+
+	if err := storage.AddMessage(msg3, mboxID1); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("Unexpected error upon adding duplicate message: expected \"%s\", got \"%v\"", ErrDuplicate, err)
+	}
+
+	storage.DeleteMailbox(mboxID1)
+
+	storage, err = NewFileStorage(path)
+
+	if err != nil {
+		t.Fatalf("Cannot reopen file storage: %s", err)
+	}
+
+	if mbox := storage.GetMailbox(mboxID1); mbox != nil {
+		t.Errorf("Deleted mailbox is restored: %v", mbox)
+	}
+
+	for _, msg := range []*message.Message{msg1, msg2} {
+		if restored := storage.GetMessage(msg.ID); restored != nil {
+			t.Errorf("Message of deleted mailbox is restored: %s", restored.ID)
+		}
+	}
+
+	if restored := storage.GetMessage(msg3.ID); restored == nil || restored.GetRawData() != msg3.GetRawData() {
+		t.Errorf("Message of another mailbox is not restored intact: %v", restored)
+	}
+}
+
+func TestFileStorageWriteFailure(t *testing.T) {
+	var path = t.TempDir()
+
+	var storage, err = NewFileStorage(path)
+
+	if err != nil {
+		t.Fatalf("Cannot create file storage: %s", err)
+	}
+
+	var mboxID = "mailbox_1"
+
+	storage.AddMailbox(mboxID)
+
+	var subscription = storage.Subscribe(16)
+
+	defer subscription.Close()
+
+	// Message files cannot be written without messages directory.
+	if err := os.RemoveAll(filepath.Join(path, messagesDir)); err != nil {
+		t.Fatalf("Cannot remove messages directory: %s", err)
+	}
+
+	var msg = message.NewMessage("Subject: Lost\r\n\r\nLost")
+
+	if err := storage.AddMessage(msg, mboxID); err == nil {
+		t.Fatal("Adding message is expected to fail")
+	}
+
+	if stored := storage.GetMessage(msg.ID); stored != nil {
+		t.Errorf("Message which could not be written is stored: %s", stored.ID)
+	}
+
+	select {
+	case event := <-subscription.Events:
+		t.Errorf("No event is expected to be published, got %+v", event)
+	default:
+	}
+}
@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"zinktray/app/mailbox"
+	"zinktray/app/message"
+)
+
+// ErrInvalidCursor is returned upon listing with cursor which is malformed or issued for another sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrInvalidOrder is returned upon listing with sort order not supported by the list.
+var ErrInvalidOrder = errors.New("invalid sort order")
+
+// Order describes sort order of a list.
+type Order string
+
+const (
+	// OrderNewest sorts items newest first. Default for message lists.
+	OrderNewest Order = "newest"
+
+	// OrderOldest sorts items oldest first. Default for mailbox lists.
+	OrderOldest Order = "oldest"
+
+	// OrderSubject sorts messages by decoded subject alphabetically, case-insensitive.
+	OrderSubject Order = "subject"
+
+	// OrderSize sorts messages by size, largest first.
+	OrderSize Order = "size"
+
+	// OrderID sorts mailboxes by ID alphabetically.
+	OrderID Order = "id"
+)
+
+// ListOptions describes which part of a list to return and in what order.
+type ListOptions struct {
+	// Order contains sort order. Empty order stands for list default.
+	Order Order
+
+	// Limit contains maximum number of items to return. Zero stands for no limit.
+	Limit int
+
+	// Cursor contains cursor returned along with the previous page. Empty cursor stands for the first page.
+	Cursor string
+}
+
+// MessagePage structure represents part of message list.
+type MessagePage struct {
+	// Messages contains messages of the page.
+	Messages []*message.Message
+
+	// Total contains the number of messages in the whole list.
+	Total int
+
+	// NextCursor contains cursor of the next page. Empty for the last page.
+	NextCursor string
+}
+
+// MailboxPage structure represents part of mailbox list.
+type MailboxPage struct {
+	// Mailboxes contains mailboxes of the page.
+	Mailboxes []*mailbox.Mailbox
+
+	// Total contains the number of mailboxes in the whole list.
+	Total int
+
+	// NextCursor contains cursor of the next page. Empty for the last page.
+	NextCursor string
+}
+
+// listEntry describes list item position.
+//
+// Items are sorted by their key first, and by sequence number of their addition to the storage second. Cursor contains
+// position of the last item of the page, so that pagination is not affected by adding or deleting items in between.
+type listEntry struct {
+	// Text contains textual sort key.
+	Text string `json:"t,omitempty"`
+
+	// Number contains numeric sort key.
+	Number int `json:"n,omitempty"`
+
+	// Sequence contains sequence number of item addition to the storage.
+	Sequence uint64 `json:"s"`
+
+	// Order contains sort order cursor is issued for. Empty for list items.
+	Order Order `json:"o,omitempty"`
+
+	// item contains list item itself.
+	item any
+}
+
+// newMessageEntry creates list entry of a message for provided sort order.
+func newMessageEntry(msg *message.Message, sequence uint64, order Order) listEntry {
+	entry := listEntry{Sequence: sequence, item: msg}
+
+	switch order {
+	case OrderSubject:
+		entry.Text = strings.ToLower(msg.GetSummary().Subject)
+	case OrderSize:
+		entry.Number = msg.GetSummary().Size
+	}
+
+	return entry
+}
+
+// newMailboxEntry creates list entry of a mailbox for provided sort order.
+func newMailboxEntry(mbx *mailbox.Mailbox, sequence uint64, order Order) listEntry {
+	entry := listEntry{Sequence: sequence, item: mbx}
+
+	if order == OrderID {
+		entry.Text = mbx.ID
+	}
+
+	return entry
+}
+
+// entryLess tests whether entry a precedes entry b in provided sort order.
+func entryLess(a, b listEntry, order Order) bool {
+	switch order {
+	case OrderOldest:
+		return a.Sequence < b.Sequence
+	case OrderSubject, OrderID:
+		if a.Text != b.Text {
+			return a.Text < b.Text
+		}
+	case OrderSize:
+		if a.Number != b.Number {
+			return a.Number > b.Number
+		}
+	}
+
+	// Items with equal keys go newest first.
+	return a.Sequence > b.Sequence
+}
+
+// paginate sorts list entries and cuts the page out of them.
+//
+// Returns entries of the page and cursor of the next page, which is empty for the last page.
+func paginate(entries []listEntry, options ListOptions) ([]listEntry, string, error) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entryLess(entries[i], entries[j], options.Order)
+	})
+
+	start := 0
+
+	if options.Cursor != "" {
+		cursor, err := decodeCursor(options.Cursor)
+
+		if err != nil || cursor.Order != options.Order {
+			return nil, "", ErrInvalidCursor
+		}
+
+		start = sort.Search(len(entries), func(i int) bool {
+			return entryLess(cursor, entries[i], options.Order)
+		})
+	}
+
+	end := len(entries)
+
+	if options.Limit > 0 && start+options.Limit < end {
+		end = start + options.Limit
+	}
+
+	page := entries[start:end]
+
+	if end == len(entries) || len(page) == 0 {
+		return page, "", nil
+	}
+
+	last := page[len(page)-1]
+	last.Order = options.Order
+
+	return page, encodeCursor(last), nil
+}
+
+// newMessagePage sorts message list entries and cuts the page out of them.
+func newMessagePage(entries []listEntry, options ListOptions) (*MessagePage, error) {
+	page, cursor, err := paginate(entries, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MessagePage{
+		Messages:   make([]*message.Message, 0, len(page)),
+		Total:      len(entries),
+		NextCursor: cursor,
+	}
+
+	for _, entry := range page {
+		result.Messages = append(result.Messages, entry.item.(*message.Message))
+	}
+
+	return result, nil
+}
+
+// newMailboxPage sorts mailbox list entries and cuts the page out of them.
+func newMailboxPage(entries []listEntry, options ListOptions) (*MailboxPage, error) {
+	page, cursor, err := paginate(entries, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MailboxPage{
+		Mailboxes:  make([]*mailbox.Mailbox, 0, len(page)),
+		Total:      len(entries),
+		NextCursor: cursor,
+	}
+
+	for _, entry := range page {
+		result.Mailboxes = append(result.Mailboxes, entry.item.(*mailbox.Mailbox))
+	}
+
+	return result, nil
+}
+
+// encodeCursor converts list entry into opaque cursor.
+func encodeCursor(entry listEntry) string {
+	data, _ := json.Marshal(entry)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor converts opaque cursor back into list entry.
+func decodeCursor(cursor string) (listEntry, error) {
+	var entry listEntry
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil {
+		return entry, err
+	}
+
+	err = json.Unmarshal(data, &entry)
+
+	return entry, err
+}
+
+// validateMessageOrder checks message list sort order and replaces empty order with the default one.
+func validateMessageOrder(options *ListOptions) error {
+	switch options.Order {
+	case "":
+		options.Order = OrderNewest
+	case OrderNewest, OrderOldest, OrderSubject, OrderSize:
+	default:
+		return ErrInvalidOrder
+	}
+
+	return nil
+}
+
+// validateMailboxOrder checks mailbox list sort order and replaces empty order with the default one.
+func validateMailboxOrder(options *ListOptions) error {
+	switch options.Order {
+	case "":
+		options.Order = OrderOldest
+	case OrderNewest, OrderOldest, OrderID:
+	default:
+		return ErrInvalidOrder
+	}
+
+	return nil
+}
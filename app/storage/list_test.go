@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"testing"
+	"zinktray/app/message"
+	"zinktray/app/message/filter"
+)
+
+func TestListMessages(t *testing.T) {
+	var storage = NewMemoryStorage()
+
+	var mboxID = "mailbox"
+
+	storage.AddMailbox(mboxID)
+
+	for i, subject := range []string{"b", "C", "a", "b"} {
+		msg := message.NewMessage(fmt.Sprintf("Subject: %s\r\n\r\n%s", subject, make([]byte, i%3)))
+		msg.ID = fmt.Sprintf("message_%d", i+1)
+
+		_ = storage.AddMessage(msg, mboxID)
+	}
+
+	var cases = []struct {
+		order    Order
+		expected []string
+	}{
+		{"", []string{"message_4", "message_3", "message_2", "message_1"}},
+		{OrderNewest, []string{"message_4", "message_3", "message_2", "message_1"}},
+		{OrderOldest, []string{"message_1", "message_2", "message_3", "message_4"}},
+		{OrderSubject, []string{"message_3", "message_4", "message_1", "message_2"}},
+		{OrderSize, []string{"message_3", "message_2", "message_4", "message_1"}},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.order), func(t *testing.T) {
+			for _, limit := range []int{0, 1, 3, 4, 5} {
+				msgIDs := listAllMessages(t, storage, mboxID, nil, ListOptions{Order: c.order, Limit: limit})
+
+				if !slices.Equal(msgIDs, c.expected) {
+					t.Fatalf("Messages do not match with limit %d: got %v, expected %v", limit, msgIDs, c.expected)
+				}
+			}
+		})
+	}
+
+	t.Run("filter", func(t *testing.T) {
+		var messageFilter = &filter.Filter{Subject: regexp.MustCompile("^b$")}
+		var expected = []string{"message_4", "message_1"}
+
+		var msgIDs = listAllMessages(t, storage, mboxID, messageFilter, ListOptions{Limit: 1})
+
+		if !slices.Equal(msgIDs, expected) {
+			t.Fatalf("Messages do not match: got %v, expected %v", msgIDs, expected)
+		}
+	})
+
+	t.Run("deletion in between", func(t *testing.T) {
+		page, _ := storage.ListMessages(mboxID, nil, ListOptions{Order: OrderOldest, Limit: 2})
+
+		storage.DeleteMessage("message_2")
+		storage.DeleteMessage("message_3")
+
+		page, err := storage.ListMessages(mboxID, nil, ListOptions{Order: OrderOldest, Cursor: page.NextCursor})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if len(page.Messages) != 1 || page.Messages[0].ID != "message_4" || page.Total != 2 {
+			t.Fatalf("Page does not match: got %d of %d messages", len(page.Messages), page.Total)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := storage.ListMessages(mboxID, nil, ListOptions{Order: OrderID}); !errors.Is(err, ErrInvalidOrder) {
+			t.Fatalf("Error is expected to be \"%s\", got \"%s\"", ErrInvalidOrder, err)
+		}
+
+		page, _ := storage.ListMessages(mboxID, nil, ListOptions{Limit: 1})
+
+		for _, options := range []ListOptions{{Cursor: "garbage"}, {Cursor: page.NextCursor, Order: OrderSize}} {
+			if _, err := storage.ListMessages(mboxID, nil, options); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("Error is expected to be \"%s\", got \"%s\"", ErrInvalidCursor, err)
+			}
+		}
+	})
+}
+
+func TestListMailboxes(t *testing.T) {
+	var storage = NewMemoryStorage()
+
+	for _, mboxID := range []string{"beta", "alpha", "Gamma", "delta"} {
+		storage.AddMailbox(mboxID)
+	}
+
+	var cases = []struct {
+		contains string
+		order    Order
+		expected []string
+	}{
+		{"", "", []string{"beta", "alpha", "Gamma", "delta"}},
+		{"", OrderNewest, []string{"delta", "Gamma", "alpha", "beta"}},
+		{"", OrderID, []string{"Gamma", "alpha", "beta", "delta"}},
+		{"TA", OrderOldest, []string{"beta", "delta"}},
+		{"ga", OrderOldest, []string{"Gamma"}},
+	}
+
+	for _, c := range cases {
+		var mboxIDs []string
+		var options = ListOptions{Order: c.order, Limit: 1}
+
+		for {
+			page, err := storage.ListMailboxes(c.contains, options)
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if page.Total != len(c.expected) {
+				t.Fatalf("Total does not match: got %d, expected %d", page.Total, len(c.expected))
+			}
+
+			for _, mbx := range page.Mailboxes {
+				mboxIDs = append(mboxIDs, mbx.ID)
+			}
+
+			if options.Cursor = page.NextCursor; options.Cursor == "" {
+				break
+			}
+		}
+
+		if !slices.Equal(mboxIDs, c.expected) {
+			t.Errorf("Mailboxes do not match for %q, %q: got %v, expected %v", c.contains, c.order, mboxIDs, c.expected)
+		}
+	}
+}
+
+// listAllMessages walks through all pages of message list and returns IDs of listed messages.
+func listAllMessages(
+	t *testing.T,
+	storage *MemoryStorage,
+	mboxID string,
+	messageFilter *filter.Filter,
+	options ListOptions,
+) []string {
+	t.Helper()
+
+	var msgIDs []string
+
+	for {
+		page, err := storage.ListMessages(mboxID, messageFilter, options)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		for _, msg := range page.Messages {
+			msgIDs = append(msgIDs, msg.ID)
+		}
+
+		if options.Cursor = page.NextCursor; options.Cursor == "" {
+			return msgIDs
+		}
+	}
+}
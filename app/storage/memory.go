@@ -0,0 +1,516 @@
+package storage
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"zinktray/app/mailbox"
+	"zinktray/app/message"
+	"zinktray/app/message/filter"
+	"zinktray/app/search"
+)
+
+// MemoryStorage represents central storage keeping everything mail in memory.
+type MemoryStorage struct {
+	mailboxMutex sync.RWMutex
+	messageMutex sync.RWMutex
+
+	// Contains list of all registered mailboxes.
+	mailboxList *list.List
+
+	// Maps mailbox ID to its respective list element.
+	mailboxElements map[string]*list.Element
+
+	// Contains list of all registered messages.
+	messageList *list.List
+
+	// Maps message ID to its respective list element.
+	messageElements map[string]*list.Element
+
+	// Maps mailbox ID to a list of message IDs belonging to this mailbox.
+	mailboxMessageIDs map[string]*list.List
+
+	// Maps message ID to its respective list element inside mailbox it belongs to.
+	mailboxMessageIDElements map[string]*list.Element
+
+	// Maps message ID to ID of mailbox it belongs to.
+	messageMailboxIDs map[string]string
+
+	// Delivers storage events to subscribers.
+	events *EventBus
+
+	// Indexes contents of stored messages.
+	index *search.Index
+
+	// Maps mailbox ID to sequence number of its registration.
+	mailboxSequences map[string]uint64
+
+	// Contains sequence number of the last mailbox registration.
+	mailboxSequence uint64
+
+	// Maps message ID to sequence number of its addition.
+	messageSequences map[string]uint64
+
+	// Contains sequence number of the last message addition.
+	messageSequence uint64
+}
+
+// AddMailbox registers mailbox ID and returns corresponding mailbox.
+//
+// When mailbox ID is already registered returns that mailbox.
+func (storage *MemoryStorage) AddMailbox(mailboxId string) *mailbox.Mailbox {
+	storage.mailboxMutex.Lock()
+
+	defer storage.mailboxMutex.Unlock()
+
+	var mbx *mailbox.Mailbox
+
+	if element, ok := storage.mailboxElements[mailboxId]; ok {
+		if m, ok := element.Value.(*mailbox.Mailbox); ok {
+			return m
+		}
+	}
+
+	mbx = mailbox.NewMailbox(mailboxId)
+
+	storage.mailboxElements[mbx.ID] = storage.mailboxList.PushBack(mbx)
+	storage.mailboxMessageIDs[mailboxId] = list.New()
+
+	storage.mailboxSequence++
+	storage.mailboxSequences[mbx.ID] = storage.mailboxSequence
+
+	storage.events.publish(Event{Type: EventMailboxAdded, MailboxID: mbx.ID})
+
+	return mbx
+}
+
+// AddMessage stores new message and binds it to mailbox with provided ID.
+// Returns ErrDuplicate error upon adding message with an ID that is already present in the storage in any mailbox.
+//
+// EventMessageAdded is published only once the message is stored.
+func (storage *MemoryStorage) AddMessage(msg *message.Message, mailboxID string) error {
+	if err := storage.addMessage(msg, mailboxID); err != nil {
+		return err
+	}
+
+	// Index has a lock of its own, so that readers are not blocked while message is indexed.
+	storage.index.Add(msg)
+
+	// Message deleted while being indexed is removed from index once again, and its addition is not published.
+	if storage.GetMessage(msg.ID) != msg {
+		storage.index.Remove(msg.ID)
+
+		return nil
+	}
+
+	storage.events.publish(Event{Type: EventMessageAdded, MailboxID: mailboxID, MessageID: msg.ID, Message: msg})
+
+	return nil
+}
+
+// addMessage binds new message to mailbox with provided ID without indexing it or publishing any event.
+func (storage *MemoryStorage) addMessage(msg *message.Message, mailboxID string) error {
+	storage.messageMutex.Lock()
+	storage.mailboxMutex.RLock()
+
+	defer storage.mailboxMutex.RUnlock()
+	defer storage.messageMutex.Unlock()
+
+	element, ok := storage.mailboxElements[mailboxID]
+	if !ok {
+		return ErrMailboxNotRegistered
+	}
+
+	mbx, ok := element.Value.(*mailbox.Mailbox)
+	if !ok {
+		return ErrMailboxNotRegistered
+	}
+
+	if _, ok := storage.messageElements[msg.ID]; ok {
+		return ErrDuplicate
+	}
+
+	storage.messageElements[msg.ID] = storage.messageList.PushFront(msg)
+	storage.mailboxMessageIDElements[msg.ID] = storage.mailboxMessageIDs[mailboxID].PushFront(msg.ID)
+	storage.messageMailboxIDs[msg.ID] = mbx.ID
+
+	storage.messageSequence++
+	storage.messageSequences[msg.ID] = storage.messageSequence
+
+	return nil
+}
+
+// CountMailboxes returns the number of registered mailboxes.
+func (storage *MemoryStorage) CountMailboxes() int {
+	storage.mailboxMutex.RLock()
+
+	defer storage.mailboxMutex.RUnlock()
+
+	return storage.mailboxList.Len()
+}
+
+// CountMessages returns the number of stored messages bound to specified mailbox.
+func (storage *MemoryStorage) CountMessages(mailboxId string) int {
+	storage.mailboxMutex.RLock()
+
+	defer storage.mailboxMutex.RUnlock()
+
+	if element, ok := storage.mailboxElements[mailboxId]; ok {
+		if mbx, ok := element.Value.(*mailbox.Mailbox); ok {
+			return storage.mailboxMessageIDs[mbx.ID].Len()
+		}
+	}
+
+	return 0
+}
+
+// DeleteMailbox deletes registered mailbox along with all its messages.
+func (storage *MemoryStorage) DeleteMailbox(mailboxID string) {
+	storage.deleteMailbox(mailboxID)
+}
+
+// deleteMailbox deletes registered mailbox along with all its messages and returns IDs of the messages deleted.
+func (storage *MemoryStorage) deleteMailbox(mailboxID string) []string {
+	var messageIDs []string
+
+	storage.messageMutex.Lock()
+	storage.mailboxMutex.Lock()
+
+	defer storage.messageMutex.Unlock()
+	defer storage.mailboxMutex.Unlock()
+
+	if msgIDList, ok := storage.mailboxMessageIDs[mailboxID]; ok {
+		next := msgIDList.Front()
+
+		for next != nil {
+			if messageID, ok := next.Value.(string); ok {
+				delete(storage.mailboxMessageIDElements, messageID)
+				delete(storage.messageMailboxIDs, messageID)
+
+				if element, ok := storage.messageElements[messageID]; ok {
+					storage.messageList.Remove(element)
+
+					delete(storage.messageElements, messageID)
+				}
+
+				delete(storage.messageSequences, messageID)
+
+				storage.index.Remove(messageID)
+
+				messageIDs = append(messageIDs, messageID)
+
+				storage.events.publish(Event{Type: EventMessageDeleted, MailboxID: mailboxID, MessageID: messageID})
+			}
+
+			next = next.Next()
+		}
+
+		delete(storage.mailboxMessageIDs, mailboxID)
+	}
+
+	if element, ok := storage.mailboxElements[mailboxID]; ok {
+		storage.mailboxList.Remove(element)
+
+		delete(storage.mailboxElements, mailboxID)
+		delete(storage.mailboxSequences, mailboxID)
+
+		storage.events.publish(Event{Type: EventMailboxDeleted, MailboxID: mailboxID})
+	}
+
+	return messageIDs
+}
+
+// DeleteMessage deletes stored message.
+func (storage *MemoryStorage) DeleteMessage(messageID string) {
+	storage.messageMutex.Lock()
+	storage.mailboxMutex.Lock()
+
+	defer storage.messageMutex.Unlock()
+	defer storage.mailboxMutex.Unlock()
+
+	if mailboxID, ok := storage.messageMailboxIDs[messageID]; ok {
+		if element, ok := storage.mailboxMessageIDElements[messageID]; ok {
+			storage.mailboxMessageIDs[mailboxID].Remove(element)
+
+			delete(storage.mailboxMessageIDElements, messageID)
+		}
+
+		delete(storage.messageMailboxIDs, messageID)
+		delete(storage.messageSequences, messageID)
+
+		storage.index.Remove(messageID)
+
+		storage.events.publish(Event{Type: EventMessageDeleted, MailboxID: mailboxID, MessageID: messageID})
+	}
+
+	if element, ok := storage.messageElements[messageID]; ok {
+		storage.messageList.Remove(element)
+
+		delete(storage.messageElements, messageID)
+	}
+}
+
+// GetMailbox returns registered mailbox.
+//
+// Returns nil for unregistered mailboxes.
+func (storage *MemoryStorage) GetMailbox(mailboxId string) *mailbox.Mailbox {
+	storage.mailboxMutex.RLock()
+
+	defer storage.mailboxMutex.RUnlock()
+
+	if element, ok := storage.mailboxElements[mailboxId]; ok {
+		if m, ok := element.Value.(*mailbox.Mailbox); ok {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// GetMailboxes returns a slice of all registered mailboxes.
+func (storage *MemoryStorage) GetMailboxes() []*mailbox.Mailbox {
+	storage.mailboxMutex.RLock()
+
+	defer storage.mailboxMutex.RUnlock()
+
+	var mailboxes []*mailbox.Mailbox
+
+	if storage.mailboxList.Len() > 0 {
+		mailboxes = make([]*mailbox.Mailbox, 0, storage.mailboxList.Len())
+		next := storage.mailboxList.Front()
+
+		for next != nil {
+			if mbx, ok := next.Value.(*mailbox.Mailbox); ok {
+				mailboxes = append(mailboxes, mbx)
+			}
+
+			next = next.Next()
+		}
+	}
+
+	return mailboxes
+}
+
+// GetMessage returns stored message.
+//
+// Returns nil for unknown message.
+func (storage *MemoryStorage) GetMessage(messageId string) *message.Message {
+	storage.messageMutex.RLock()
+
+	defer storage.messageMutex.RUnlock()
+
+	return storage.getMessage(messageId)
+}
+
+// GetMessages returns a list of all known messages bound to specified mailbox.
+func (storage *MemoryStorage) GetMessages(mailboxId string) []*message.Message {
+	storage.mailboxMutex.RLock()
+	storage.messageMutex.RLock()
+
+	defer storage.mailboxMutex.RUnlock()
+	defer storage.messageMutex.RUnlock()
+
+	var result []*message.Message
+
+	if l, ok := storage.mailboxMessageIDs[mailboxId]; ok {
+		result = make([]*message.Message, 0, l.Len())
+		next := l.Front()
+
+		for next != nil {
+			if msgID, ok := next.Value.(string); ok {
+				if element, ok := storage.messageElements[msgID]; ok {
+					if msg, ok := element.Value.(*message.Message); ok {
+						result = append(result, msg)
+					}
+				}
+			}
+
+			next = next.Next()
+		}
+	}
+
+	return result
+}
+
+// GetStats returns totals of registered mailboxes and stored messages.
+func (storage *MemoryStorage) GetStats() Stats {
+	storage.messageMutex.RLock()
+	storage.mailboxMutex.RLock()
+
+	defer storage.messageMutex.RUnlock()
+	defer storage.mailboxMutex.RUnlock()
+
+	stats := Stats{
+		Mailboxes: storage.mailboxList.Len(),
+		Messages:  storage.messageList.Len(),
+	}
+
+	for element := storage.messageList.Front(); element != nil; element = element.Next() {
+		if msg, ok := element.Value.(*message.Message); ok {
+			stats.Size += int64(msg.GetSummary().Size)
+			stats.CompressedSize += int64(msg.GetCompressedSize())
+		}
+	}
+
+	return stats
+}
+
+// ListMailboxes returns a page of registered mailboxes with IDs containing provided substring, case-insensitive.
+//
+// Returns ErrInvalidOrder error for sort order other than OrderNewest, OrderOldest or OrderID, and ErrInvalidCursor
+// error for malformed cursor.
+func (storage *MemoryStorage) ListMailboxes(contains string, options ListOptions) (*MailboxPage, error) {
+	if err := validateMailboxOrder(&options); err != nil {
+		return nil, err
+	}
+
+	storage.mailboxMutex.RLock()
+
+	defer storage.mailboxMutex.RUnlock()
+
+	contains = strings.ToLower(contains)
+	entries := make([]listEntry, 0, storage.mailboxList.Len())
+
+	for next := storage.mailboxList.Front(); next != nil; next = next.Next() {
+		if mbx, ok := next.Value.(*mailbox.Mailbox); ok && strings.Contains(strings.ToLower(mbx.ID), contains) {
+			entries = append(entries, newMailboxEntry(mbx, storage.mailboxSequences[mbx.ID], options.Order))
+		}
+	}
+
+	return newMailboxPage(entries, options)
+}
+
+// ListMessages returns a page of messages bound to specified mailbox and satisfying filter. nil filter is satisfied by
+// any message.
+//
+// Returns ErrInvalidOrder error for sort order other than OrderNewest, OrderOldest, OrderSubject or OrderSize, and
+// ErrInvalidCursor error for malformed cursor.
+func (storage *MemoryStorage) ListMessages(
+	mailboxID string,
+	messageFilter *filter.Filter,
+	options ListOptions,
+) (*MessagePage, error) {
+	if err := validateMessageOrder(&options); err != nil {
+		return nil, err
+	}
+
+	storage.mailboxMutex.RLock()
+	storage.messageMutex.RLock()
+
+	defer storage.mailboxMutex.RUnlock()
+	defer storage.messageMutex.RUnlock()
+
+	entries := make([]listEntry, 0)
+
+	if l, ok := storage.mailboxMessageIDs[mailboxID]; ok {
+		for next := l.Front(); next != nil; next = next.Next() {
+			if msg := storage.getMessage(next.Value.(string)); msg != nil {
+				if messageFilter == nil || messageFilter.Match(msg) {
+					entries = append(entries, newMessageEntry(msg, storage.messageSequences[msg.ID], options.Order))
+				}
+			}
+		}
+	}
+
+	return newMessagePage(entries, options)
+}
+
+// SearchMessages returns a page of stored messages satisfying query.
+//
+// Searches messages bound to specified mailbox, or all stored messages when mailbox ID is empty. Returns
+// ErrInvalidOrder error for sort order other than OrderNewest, OrderOldest, OrderSubject or OrderSize, and
+// ErrInvalidCursor error for malformed cursor.
+func (storage *MemoryStorage) SearchMessages(
+	query *search.Query,
+	mailboxID string,
+	options ListOptions,
+) (*MessagePage, error) {
+	if err := validateMessageOrder(&options); err != nil {
+		return nil, err
+	}
+
+	storage.messageMutex.RLock()
+
+	defer storage.messageMutex.RUnlock()
+
+	messageIDs := storage.index.Search(query)
+	entries := make([]listEntry, 0, len(messageIDs))
+
+	for _, messageID := range messageIDs {
+		if mailboxID != "" && storage.messageMailboxIDs[messageID] != mailboxID {
+			continue
+		}
+
+		if msg := storage.getMessage(messageID); msg != nil {
+			entries = append(entries, newMessageEntry(msg, storage.messageSequences[messageID], options.Order))
+		}
+	}
+
+	return newMessagePage(entries, options)
+}
+
+// checkMessage tests whether message with provided ID could be added to mailbox with provided ID.
+//
+// Returns the same errors as AddMessage does.
+func (storage *MemoryStorage) checkMessage(messageID string, mailboxID string) error {
+	storage.messageMutex.RLock()
+	storage.mailboxMutex.RLock()
+
+	defer storage.mailboxMutex.RUnlock()
+	defer storage.messageMutex.RUnlock()
+
+	if _, ok := storage.mailboxElements[mailboxID]; !ok {
+		return ErrMailboxNotRegistered
+	}
+
+	if _, ok := storage.messageElements[messageID]; ok {
+		return ErrDuplicate
+	}
+
+	return nil
+}
+
+// getMessage returns stored message. Storage must be locked for reading.
+//
+// Returns nil for unknown message.
+func (storage *MemoryStorage) getMessage(messageID string) *message.Message {
+	if element, ok := storage.messageElements[messageID]; ok {
+		if m, ok := element.Value.(*message.Message); ok {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// Subscribe creates storage event subscription with event buffer of provided size.
+//
+// Subscription must be closed as soon as it is not needed anymore.
+func (storage *MemoryStorage) Subscribe(bufferSize int) *Subscription {
+	return storage.events.Subscribe(bufferSize)
+}
+
+// NewMemoryStorage creates new in-memory storage structure.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		mailboxMutex: sync.RWMutex{},
+		messageMutex: sync.RWMutex{},
+
+		mailboxList:     list.New(),
+		mailboxElements: make(map[string]*list.Element),
+
+		messageList:     list.New(),
+		messageElements: make(map[string]*list.Element),
+
+		mailboxMessageIDs:        make(map[string]*list.List),
+		mailboxMessageIDElements: make(map[string]*list.Element),
+
+		messageMailboxIDs: make(map[string]string),
+
+		events: NewEventBus(),
+		index:  search.NewIndex(),
+
+		mailboxSequences: make(map[string]uint64),
+		messageSequences: make(map[string]uint64),
+	}
+}
@@ -2,13 +2,15 @@ package storage
 
 import (
 	"errors"
+	"slices"
 	"testing"
 	"time"
 	"zinktray/app/message"
+	"zinktray/app/search"
 )
 
 func TestAddMailbox(t *testing.T) {
-	var storage = NewStorage()
+	var storage = NewMemoryStorage()
 
 	var mboxID1 = "test-mailbox-1"
 	var mboxID2 = mboxID1
@@ -38,7 +40,7 @@ func TestAddMailbox(t *testing.T) {
 }
 
 func TestAddMessage(t *testing.T) {
-	var storage = NewStorage()
+	var storage = NewMemoryStorage()
 
 	var mboxID = "test-mailbox"
 
@@ -69,7 +71,7 @@ func TestAddMessage(t *testing.T) {
 }
 
 func TestAddDuplicate(t *testing.T) {
-	var storage = NewStorage()
+	var storage = NewMemoryStorage()
 
 	var mboxID1 = "mailbox_1"
 	var mboxID2 = "mailbox_2"
@@ -93,7 +95,7 @@ func TestAddDuplicate(t *testing.T) {
 }
 
 func TestDeleteMailbox(t *testing.T) {
-	var storage = NewStorage()
+	var storage = NewMemoryStorage()
 
 	var mboxID = "mailbox_1"
 	var msgID = "message_1"
@@ -121,7 +123,7 @@ func TestDeleteMailbox(t *testing.T) {
 }
 
 func TestDeleteMessage(t *testing.T) {
-	var storage = NewStorage()
+	var storage = NewMemoryStorage()
 
 	var mboxID = "mailbox_1"
 	var msgID1 = "message_1"
@@ -139,10 +141,14 @@ func TestDeleteMessage(t *testing.T) {
 			t.Errorf("Message \"%s\" found after deletion", ID)
 		}
 	}
+
+	if msgCount := storage.CountMessages(mboxID); msgCount > 0 {
+		t.Fatalf("Mailbox appears to still have messages attached")
+	}
 }
 
 func TestGetMailbox(t *testing.T) {
-	var storage = NewStorage()
+	var storage = NewMemoryStorage()
 
 	var mboxID = "mailbox_1"
 
@@ -159,8 +165,29 @@ func TestGetMailbox(t *testing.T) {
 	}
 }
 
+func TestGetStats(t *testing.T) {
+	var storage = NewMemoryStorage()
+	var rawData = "Subject: Test\r\n\r\nHello\r\n"
+
+	storage.AddMailbox("mailbox_1")
+	storage.AddMailbox("mailbox_2")
+
+	_ = storage.AddMessage(message.NewMessage(rawData), "mailbox_1")
+	_ = storage.AddMessage(message.NewMessage(rawData), "mailbox_2")
+
+	var stats = storage.GetStats()
+
+	if stats.Mailboxes != 2 || stats.Messages != 2 || stats.Size != int64(2*len(rawData)) {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	if stats.CompressedSize <= 0 {
+		t.Errorf("Compressed size is expected to be positive, got %d", stats.CompressedSize)
+	}
+}
+
 func TestGetMailboxes(t *testing.T) {
-	var storage = NewStorage()
+	var storage = NewMemoryStorage()
 
 	var mboxID1 = "mailbox_1"
 	var mboxID2 = "mailbox_2"
@@ -194,7 +221,7 @@ func TestGetMailboxes(t *testing.T) {
 }
 
 func TestGetMessages(t *testing.T) {
-	var storage = NewStorage()
+	var storage = NewMemoryStorage()
 
 	var mboxID = "mailbox_1"
 	var msgID1 = "message_1"
@@ -228,3 +255,63 @@ func TestGetMessages(t *testing.T) {
 		}
 	}
 }
+
+func TestSearchMessages(t *testing.T) {
+	var storage = NewMemoryStorage()
+
+	var mboxID1 = "mailbox_1"
+	var mboxID2 = "mailbox_2"
+
+	var newMessage = func(id string, subject string, receivedAt int64) *message.Message {
+		msg := message.NewMessage("Subject: " + subject + "\r\n\r\nBody")
+		msg.ID = id
+		msg.ReceivedAt = time.Unix(receivedAt, 0)
+
+		return msg
+	}
+
+	storage.AddMailbox(mboxID1)
+	storage.AddMailbox(mboxID2)
+
+	_ = storage.AddMessage(newMessage("message_1", "Hello", 1), mboxID1)
+	_ = storage.AddMessage(newMessage("message_2", "Hello again", 3), mboxID2)
+	_ = storage.AddMessage(newMessage("message_3", "Goodbye", 2), mboxID1)
+	_ = storage.AddMessage(newMessage("message_4", "Hello once more", 2), mboxID1)
+
+	storage.DeleteMessage("message_4")
+
+	var query = &search.Query{Subject: []string{"hello"}}
+
+	var cases = []struct {
+		mailboxID string
+		expected  []string
+	}{
+		{"", []string{"message_2", "message_1"}},
+		{mboxID1, []string{"message_1"}},
+		{"unknown", []string{}},
+	}
+
+	for _, c := range cases {
+		var msgIDs = make([]string, 0)
+
+		page, err := storage.SearchMessages(query, c.mailboxID, ListOptions{})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		for _, msg := range page.Messages {
+			msgIDs = append(msgIDs, msg.ID)
+		}
+
+		if !slices.Equal(msgIDs, c.expected) {
+			t.Errorf("Messages found in \"%s\" do not match: got %v, expected %v", c.mailboxID, msgIDs, c.expected)
+		}
+	}
+
+	storage.DeleteMailbox(mboxID2)
+
+	if page, _ := storage.SearchMessages(query, "", ListOptions{}); page.Total != 1 || page.Messages[0].ID != "message_1" {
+		t.Fatalf("Deleted mailbox messages are expected not to be found")
+	}
+}
@@ -0,0 +1,208 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+	"zinktray/app/config"
+	"zinktray/app/storage"
+)
+
+// SignatureHeader contains name of the header carrying HMAC-SHA256 signature of notification body.
+//
+// Signature is given as "sha256=" followed by hex-encoded HMAC of the body keyed with webhook secret.
+const SignatureHeader = "X-Zinktray-Signature"
+
+// EventHeader contains name of the header carrying type of the event notified of.
+const EventHeader = "X-Zinktray-Event"
+
+// Notifier posts notifications on message arrival to configured webhooks.
+type Notifier struct {
+	// config contains webhook configuration.
+	config config.WebhookConfig
+
+	// client contains HTTP client notifications are posted with.
+	client *http.Client
+
+	// subscription contains storage event subscription. nil when no webhook is configured.
+	subscription *storage.Subscription
+}
+
+// Start wires-up delivery of notifications on message arrival.
+//
+// Every webhook has its own queue of notifications awaiting delivery, so that slow or failing webhook does not delay
+// the others. Notifications are dropped once the queue is full.
+//
+// Notifier is terminated as soon as ctx is cancelled. Does nothing when no webhook is configured.
+func (notifier *Notifier) Start(ctx context.Context, waitGroup *sync.WaitGroup) {
+	defer waitGroup.Done()
+
+	if notifier.subscription == nil {
+		return
+	}
+
+	defer notifier.subscription.Close()
+
+	workers := &sync.WaitGroup{}
+	queues := make([]chan []byte, len(notifier.config.Endpoints))
+
+	for i, endpoint := range notifier.config.Endpoints {
+		queues[i] = make(chan []byte, notifier.config.QueueSize)
+
+		workers.Add(1)
+
+		go notifier.deliver(ctx, workers, endpoint, queues[i])
+	}
+
+	defer workers.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-notifier.subscription.Events:
+			if !ok {
+				return
+			}
+
+			if event.Type != storage.EventMessageAdded {
+				continue
+			}
+
+			body, err := json.Marshal(newPayload(event))
+
+			if err != nil {
+				log.Printf("Cannot encode notification on message \"%s\": %s\n", event.MessageID, err)
+
+				continue
+			}
+
+			for i, endpoint := range notifier.config.Endpoints {
+				if len(endpoint.Mailboxes) > 0 && !slices.Contains(endpoint.Mailboxes, event.MailboxID) {
+					continue
+				}
+
+				select {
+				case queues[i] <- body:
+				default:
+					log.Printf("Webhook %s queue is full, dropping notification on message \"%s\"\n", endpoint.URL, event.MessageID)
+				}
+			}
+		}
+	}
+}
+
+// deliver posts queued notifications to webhook one by one until ctx is cancelled.
+func (notifier *Notifier) deliver(
+	ctx context.Context,
+	waitGroup *sync.WaitGroup,
+	endpoint config.WebhookEndpoint,
+	queue <-chan []byte,
+) {
+	defer waitGroup.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case body := <-queue:
+			notifier.send(ctx, endpoint, body)
+		}
+	}
+}
+
+// send posts notification to webhook, retrying with exponential backoff upon failure.
+func (notifier *Notifier) send(ctx context.Context, endpoint config.WebhookEndpoint, body []byte) {
+	delay := time.Duration(notifier.config.RetryInterval)
+
+	for attempt := 1; ; attempt++ {
+		err := notifier.post(ctx, endpoint, body)
+
+		if err == nil {
+			return
+		}
+
+		if attempt >= notifier.config.MaxAttempts {
+			log.Printf("Cannot notify webhook %s, giving up after %d attempts: %s\n", endpoint.URL, attempt, err)
+
+			return
+		}
+
+		log.Printf("Cannot notify webhook %s, retrying in %s: %s\n", endpoint.URL, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+	}
+}
+
+// post makes single attempt to post notification to webhook.
+func (notifier *Notifier) post(ctx context.Context, endpoint config.WebhookEndpoint, body []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, string(storage.EventMessageAdded))
+
+	if endpoint.Secret != "" {
+		request.Header.Set(SignatureHeader, Sign(endpoint.Secret, body))
+	}
+
+	response, err := notifier.client.Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	// Drain the body, so that connection may be reused.
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status: %s", response.Status)
+	}
+
+	return nil
+}
+
+// Sign computes signature of notification body keyed with webhook secret, as found in SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewNotifier creates new webhook notifier structure.
+//
+// Storage events are subscribed to right away, so that no message stored after creation is missed.
+func NewNotifier(store storage.Storage, config config.WebhookConfig) *Notifier {
+	notifier := &Notifier{
+		config: config,
+		client: &http.Client{Timeout: time.Duration(config.Timeout)},
+	}
+
+	if len(config.Endpoints) > 0 {
+		notifier.subscription = store.Subscribe(config.QueueSize)
+	}
+
+	return notifier
+}
@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"zinktray/app/config"
+	"zinktray/app/message"
+	"zinktray/app/storage"
+)
+
+var rawMessage = "From: Alice <alice@example.com>\r\nTo: bob@example.com\r\nSubject: Test\r\n\r\nHello\r\n"
+
+// notification describes request received by webhook.
+type notification struct {
+	header http.Header
+	body   []byte
+}
+
+func TestNotify(t *testing.T) {
+	var attempts = 0
+	var notifications = make(chan notification, 10)
+
+	var receiver = httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+
+		notifications <- notification{header: request.Header, body: body}
+
+		// Fail the first attempt, so that notification is retried.
+		if attempts++; attempts == 1 {
+			response.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+
+	t.Cleanup(receiver.Close)
+
+	var store = storage.NewMemoryStorage()
+	var cfg = config.Default().Webhooks
+
+	cfg.RetryInterval = config.Duration(10 * time.Millisecond)
+	cfg.Endpoints = []config.WebhookEndpoint{{URL: receiver.URL, Secret: "secret"}}
+
+	startNotifier(t, store, cfg)
+
+	var envelope = &message.Envelope{
+		ReturnPath: "alice@example.com",
+		Recipients: []message.Recipient{{Address: "bob@example.com"}},
+		ClientIP:   "127.0.0.1",
+	}
+
+	var msg, err = storage.Deliver(store, "bob", rawMessage, envelope)
+
+	if err != nil {
+		t.Fatalf("Cannot deliver message: %s", err)
+	}
+
+	var first = receiveNotification(t, notifications)
+	var second = receiveNotification(t, notifications)
+
+	if string(first.body) != string(second.body) {
+		t.Errorf("Retried notification does not match: got %s, expected %s", second.body, first.body)
+	}
+
+	if signature := second.header.Get(SignatureHeader); signature != Sign("secret", second.body) {
+		t.Errorf("Unexpected signature: %q", signature)
+	}
+
+	if event := second.header.Get(EventHeader); event != "message.added" {
+		t.Errorf("Unexpected event: %q", event)
+	}
+
+	var received payload
+
+	if err := json.Unmarshal(second.body, &received); err != nil {
+		t.Fatalf("Cannot decode notification: %s", err)
+	}
+
+	if received.MailboxID != "bob" || received.Message.ID != msg.ID || received.Message.Subject != "Test" {
+		t.Errorf("Unexpected notification: %s", second.body)
+	}
+
+	if received.Message.Envelope == nil ||
+		received.Message.Envelope.ReturnPath != "alice@example.com" ||
+		len(received.Message.Envelope.Recipients) != 1 ||
+		received.Message.Envelope.Recipients[0].Address != "bob@example.com" {
+		t.Errorf("Unexpected envelope: %s", second.body)
+	}
+}
+
+func TestNotifyMailboxFilter(t *testing.T) {
+	var notifications = make(chan notification, 10)
+
+	var receiver = httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+
+		notifications <- notification{header: request.Header, body: body}
+	}))
+
+	t.Cleanup(receiver.Close)
+
+	var store = storage.NewMemoryStorage()
+	var cfg = config.Default().Webhooks
+
+	cfg.Endpoints = []config.WebhookEndpoint{{URL: receiver.URL, Mailboxes: []string{"alice"}}}
+
+	startNotifier(t, store, cfg)
+
+	for _, mailboxID := range []string{"bob", "alice"} {
+		if _, err := storage.Deliver(store, mailboxID, rawMessage, nil); err != nil {
+			t.Fatalf("Cannot deliver message: %s", err)
+		}
+	}
+
+	var received payload
+	var first = receiveNotification(t, notifications)
+
+	if err := json.Unmarshal(first.body, &received); err != nil {
+		t.Fatalf("Cannot decode notification: %s", err)
+	}
+
+	if received.MailboxID != "alice" {
+		t.Errorf("Webhook is expected to be notified of filtered mailbox only, got \"%s\"", received.MailboxID)
+	}
+
+	if signature := first.header.Get(SignatureHeader); signature != "" {
+		t.Errorf("Notification is expected to be unsigned, got %q", signature)
+	}
+
+	select {
+	case extra := <-notifications:
+		t.Errorf("Unexpected notification: %s", extra.body)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// startNotifier starts webhook notifier, which is terminated upon test completion.
+func startNotifier(t *testing.T, store storage.Storage, cfg config.WebhookConfig) {
+	var ctx, cancel = context.WithCancel(context.Background())
+	var wg = &sync.WaitGroup{}
+
+	wg.Add(1)
+
+	go NewNotifier(store, cfg).Start(ctx, wg)
+
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+}
+
+// receiveNotification awaits notification received by webhook.
+func receiveNotification(t *testing.T, notifications <-chan notification) notification {
+	select {
+	case received := <-notifications:
+		return received
+	case <-time.After(3 * time.Second):
+		t.Fatal("Webhook has not been notified")
+	}
+
+	return notification{}
+}
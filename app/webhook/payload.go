@@ -0,0 +1,24 @@
+package webhook
+
+import (
+	"zinktray/app/message"
+	"zinktray/app/storage"
+)
+
+// payload describes notification posted to webhooks upon message arrival.
+//
+// Message is described the same way as message list API does.
+type payload struct {
+	Type      storage.EventType `json:"type"`
+	MailboxID string            `json:"mailboxId"`
+	Message   message.Info      `json:"message"`
+}
+
+// newPayload creates notification on message arrival.
+func newPayload(event storage.Event) payload {
+	return payload{
+		Type:      event.Type,
+		MailboxID: event.MailboxID,
+		Message:   message.NewInfo(event.Message),
+	}
+}
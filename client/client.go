@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Client structure represents zinktray HTTP API client.
+type Client struct {
+	// baseURL contains base URL of HTTP API without trailing slash (e.g. "http://localhost:8080").
+	baseURL string
+
+	// httpClient contains HTTP client requests are made with.
+	httpClient *http.Client
+}
+
+// GetCACertificate retrieves PEM-encoded certificate mail clients are expected to trust.
+//
+// Returns ErrNotFound when TLS is not enabled.
+func (client *Client) GetCACertificate(ctx context.Context) ([]byte, error) {
+	return client.getBytes(ctx, "/api/certificates/ca", nil)
+}
+
+// do makes request to API endpoint and returns response of successful status.
+//
+// Parameters are sent in query string when body is given or method is GET, and as form otherwise. Returns StatusError
+// for any status other than 2xx.
+func (client *Client) do(
+	ctx context.Context,
+	method string,
+	path string,
+	params url.Values,
+	body io.Reader,
+	contentType string,
+) (*http.Response, error) {
+	endpoint := client.baseURL + path
+
+	if body != nil || method == http.MethodGet {
+		if len(params) > 0 {
+			endpoint += "?" + params.Encode()
+		}
+	} else {
+		body = strings.NewReader(params.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	response, err := client.httpClient.Do(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+
+		return nil, &StatusError{Method: method, Path: path, StatusCode: response.StatusCode}
+	}
+
+	return response, nil
+}
+
+// getJSON retrieves JSON-encoded response of API endpoint and decodes it into result.
+func (client *Client) getJSON(ctx context.Context, path string, params url.Values, result any) error {
+	response, err := client.do(ctx, http.MethodGet, path, params, nil, "")
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+		return fmt.Errorf("cannot decode response of %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// getBytes retrieves response body of API endpoint.
+func (client *Client) getBytes(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	response, err := client.do(ctx, http.MethodGet, path, params, nil, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	return io.ReadAll(response.Body)
+}
+
+// post sends form to API endpoint, discarding response body.
+func (client *Client) post(ctx context.Context, path string, params url.Values) error {
+	response, err := client.do(ctx, http.MethodPost, path, params, nil, "")
+
+	if err != nil {
+		return err
+	}
+
+	io.Copy(io.Discard, response.Body)
+
+	return response.Body.Close()
+}
+
+// addListOptions adds list options to request parameters.
+func addListOptions(params url.Values, options ListOptions) {
+	if options.Sort != "" {
+		params.Set("sort", string(options.Sort))
+	}
+
+	if options.Limit > 0 {
+		params.Set("limit", strconv.Itoa(options.Limit))
+	}
+
+	if options.Cursor != "" {
+		params.Set("cursor", options.Cursor)
+	}
+}
+
+// addFilter adds message filter conditions to request parameters.
+func addFilter(params url.Values, filter Filter) {
+	if filter.Recipient != "" {
+		params.Set("recipient", filter.Recipient)
+	}
+
+	if filter.From != "" {
+		params.Set("from", filter.From)
+	}
+
+	if filter.Subject != "" {
+		params.Set("subject", filter.Subject)
+	}
+
+	if !filter.After.IsZero() {
+		params.Set("after", strconv.FormatInt(filter.After.Unix(), 10))
+	}
+}
+
+// NewClient creates new HTTP API client structure.
+//
+// baseURL points to HTTP API server (e.g. "http://localhost:8080"). http.DefaultClient is used when httpClient is nil.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
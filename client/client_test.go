@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+	"zinktray/ztest"
+)
+
+func TestClient(t *testing.T) {
+	var srv = ztest.Start(t)
+	var client = NewClient(srv.APIURL+"/", nil)
+	var ctx = context.Background()
+
+	stream, err := client.StreamEvents(ctx, "bob")
+
+	if err != nil {
+		t.Fatalf("Cannot stream events: %s", err)
+	}
+
+	defer stream.Close()
+
+	id, err := client.ComposeMessage(ctx, "bob", &NewMessage{
+		From:        "alice@example.com",
+		To:          []string{"bob@example.com"},
+		Subject:     "Invoice",
+		Text:        "Total: 42",
+		Attachments: []NewAttachment{{Filename: "invoice.txt", Data: []byte("42")}},
+	})
+
+	if err != nil {
+		t.Fatalf("Cannot compose message: %s", err)
+	}
+
+	if _, err := client.AddMessage(ctx, "carol", "Subject: Hello\r\n\r\nHi\r\n"); err != nil {
+		t.Fatalf("Cannot add message: %s", err)
+	}
+
+	for _, expected := range []Event{{Type: "mailbox.added", MailboxID: "bob"}, {Type: "message.added", MailboxID: "bob", MessageID: id}} {
+		if event, err := stream.Next(); err != nil || *event != expected {
+			t.Errorf("Unexpected event: got %+v (%v), expected %+v", event, err, expected)
+		}
+	}
+
+	mailboxes, err := client.ListMailboxes(ctx, "", ListOptions{Sort: OrderID, Limit: 1})
+
+	if err != nil {
+		t.Fatalf("Cannot list mailboxes: %s", err)
+	}
+
+	if mailboxes.Total != 2 || len(mailboxes.Mailboxes) != 1 || mailboxes.Mailboxes[0].ID != "bob" || mailboxes.NextCursor == nil {
+		t.Errorf("Unexpected mailbox list: %+v", mailboxes)
+	}
+
+	messages, err := client.ListMessages(ctx, "bob", Filter{Subject: "^Inv"}, ListOptions{})
+
+	if err != nil {
+		t.Fatalf("Cannot list messages: %s", err)
+	}
+
+	if messages.Total != 1 || messages.Messages[0].ID != id || !messages.Messages[0].HasAttachments {
+		t.Errorf("Unexpected message list: %+v", messages)
+	}
+
+	found, err := client.SearchMessages(ctx, "subject:hello", "", ListOptions{})
+
+	if err != nil {
+		t.Fatalf("Cannot search messages: %s", err)
+	}
+
+	if found.Total != 1 || found.Messages[0].Subject != "Hello" {
+		t.Errorf("Unexpected search results: %+v", found)
+	}
+
+	details, err := client.GetMessage(ctx, id)
+
+	if err != nil {
+		t.Fatalf("Cannot get message: %s", err)
+	}
+
+	if details.Content.Text == nil || *details.Content.Text != "Total: 42" || len(details.Attachments) != 1 {
+		t.Errorf("Unexpected message details: %+v", details)
+	}
+
+	if data, err := client.GetAttachment(ctx, id, 0); err != nil || string(data) != "42" {
+		t.Errorf("Unexpected attachment: %q (%v)", data, err)
+	}
+
+	if _, err := client.GetMessageHtml(ctx, id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Unexpected error: expected \"%s\", got \"%v\"", ErrNotFound, err)
+	}
+
+	awaited, err := client.WaitMessage(ctx, "", Filter{Recipient: "bob@"}, time.Second)
+
+	if err != nil || awaited.ID != id {
+		t.Errorf("Unexpected awaited message: %+v (%v)", awaited, err)
+	}
+
+	if _, err := client.WaitMessage(ctx, "carol", Filter{From: "mallory"}, 10*time.Millisecond); !errors.Is(err, ErrNoMessage) {
+		t.Errorf("Unexpected error: expected \"%s\", got \"%v\"", ErrNoMessage, err)
+	}
+
+	if err := client.DeleteMessage(ctx, id); err != nil {
+		t.Fatalf("Cannot delete message: %s", err)
+	}
+
+	if _, err := client.GetMessage(ctx, id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Unexpected error: expected \"%s\", got \"%v\"", ErrNotFound, err)
+	}
+
+	if err := client.DeleteMailbox(ctx, "carol"); err != nil {
+		t.Fatalf("Cannot delete mailbox: %s", err)
+	}
+
+	if err := client.ReleaseMessage(ctx, id, []string{"bob@example.com"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Unexpected error: expected \"%s\", got \"%v\"", ErrNotFound, err)
+	}
+
+	if _, err := client.ListMessages(ctx, "bob", Filter{Subject: "("}, ListOptions{}); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Unexpected error: expected \"%s\", got \"%v\"", ErrBadRequest, err)
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	var expected = map[int]error{
+		http.StatusBadRequest:          ErrBadRequest,
+		http.StatusNotFound:            ErrNotFound,
+		http.StatusMethodNotAllowed:    ErrMethodNotAllowed,
+		http.StatusInternalServerError: ErrInternal,
+	}
+
+	for code, target := range expected {
+		var err error = &StatusError{Method: http.MethodGet, Path: "/api/messages/list", StatusCode: code}
+
+		if !errors.Is(err, target) {
+			t.Errorf("Status %d is expected to match \"%s\"", code, target)
+		}
+	}
+
+	var err error = &StatusError{Method: http.MethodPost, Path: "/api/messages/release", StatusCode: http.StatusForbidden}
+
+	if errors.Is(err, ErrNotFound) || err.Error() != "POST /api/messages/release: 403 Forbidden" {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
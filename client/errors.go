@@ -0,0 +1,56 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrBadRequest is matched by StatusError for HTTP 400 Bad Request, returned for malformed request parameters.
+var ErrBadRequest = errors.New("bad request")
+
+// ErrNotFound is matched by StatusError for HTTP 404 Not Found, returned for unknown mailboxes and messages.
+var ErrNotFound = errors.New("not found")
+
+// ErrMethodNotAllowed is matched by StatusError for HTTP 405 Method Not Allowed.
+var ErrMethodNotAllowed = errors.New("method not allowed")
+
+// ErrInternal is matched by StatusError for HTTP 500 Internal Server Error.
+var ErrInternal = errors.New("internal server error")
+
+// ErrNoMessage is returned upon awaiting a message when no matching message has been stored before timeout elapsed.
+var ErrNoMessage = errors.New("no matching message")
+
+// StatusError is returned when HTTP API responds with unexpected status.
+//
+// Use errors.Is to test for ErrBadRequest, ErrNotFound, ErrMethodNotAllowed and ErrInternal.
+type StatusError struct {
+	// Method contains HTTP method of the request.
+	Method string
+
+	// Path contains path of the endpoint requested.
+	Path string
+
+	// StatusCode contains HTTP status code of the response.
+	StatusCode int
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", err.Method, err.Path, err.StatusCode, http.StatusText(err.StatusCode))
+}
+
+// Unwrap returns error matching status code, if any.
+func (err *StatusError) Unwrap() error {
+	switch err.StatusCode {
+	case http.StatusBadRequest:
+		return ErrBadRequest
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusMethodNotAllowed:
+		return ErrMethodNotAllowed
+	case http.StatusInternalServerError:
+		return ErrInternal
+	default:
+		return nil
+	}
+}
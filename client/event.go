@@ -0,0 +1,84 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// EventStream structure represents live stream of storage events.
+type EventStream struct {
+	// body contains response body events are read from.
+	body io.ReadCloser
+
+	// scanner reads body line by line.
+	scanner *bufio.Scanner
+}
+
+// StreamEvents opens live stream of storage events, related to provided mailbox unless mailboxID is empty.
+//
+// The stream lasts until closed or ctx is cancelled, so that HTTP client must not have timeout set.
+func (client *Client) StreamEvents(ctx context.Context, mailboxID string) (*EventStream, error) {
+	params := url.Values{}
+
+	if mailboxID != "" {
+		params.Set("mailbox_id", mailboxID)
+	}
+
+	response, err := client.do(ctx, http.MethodGet, "/api/events/stream", params, nil, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &EventStream{
+		body:    response.Body,
+		scanner: bufio.NewScanner(response.Body),
+	}, nil
+}
+
+// Next awaits the next event.
+//
+// Returns io.EOF once the server ends the stream.
+func (stream *EventStream) Next() (*Event, error) {
+	var data strings.Builder
+
+	for stream.scanner.Scan() {
+		line := stream.scanner.Text()
+
+		// Event type is found in event data as well, and comments are only sent to keep connection open.
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			data.WriteString(strings.TrimPrefix(value, " "))
+
+			continue
+		}
+
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+
+		var event Event
+
+		if err := json.Unmarshal([]byte(data.String()), &event); err != nil {
+			return nil, fmt.Errorf("cannot decode event: %w", err)
+		}
+
+		return &event, nil
+	}
+
+	if err := stream.scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
+
+// Close closes the stream.
+func (stream *EventStream) Close() error {
+	return stream.body.Close()
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// ListMailboxes retrieves a page of mailbox list.
+//
+// Lists only mailboxes with IDs containing provided text unless it is empty. Mailboxes are listed in order of
+// registration by default.
+func (client *Client) ListMailboxes(ctx context.Context, contains string, options ListOptions) (*MailboxList, error) {
+	params := url.Values{}
+
+	if contains != "" {
+		params.Set("contains", contains)
+	}
+
+	addListOptions(params, options)
+
+	var result MailboxList
+
+	if err := client.getJSON(ctx, "/api/mailboxes/list", params, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// DeleteMailbox deletes mailbox along with its messages.
+//
+// Returns ErrNotFound for unknown mailbox.
+func (client *Client) DeleteMailbox(ctx context.Context, mailboxID string) error {
+	return client.post(ctx, "/api/mailboxes/delete", url.Values{"mailbox_id": {mailboxID}})
+}
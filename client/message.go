@@ -0,0 +1,196 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ListMessages retrieves a page of the list of messages stored in mailbox and satisfying filter.
+//
+// Messages are listed newest first by default. Lists nothing for unknown mailbox.
+func (client *Client) ListMessages(
+	ctx context.Context,
+	mailboxID string,
+	filter Filter,
+	options ListOptions,
+) (*MessageList, error) {
+	params := url.Values{"mailbox_id": {mailboxID}}
+
+	addFilter(params, filter)
+	addListOptions(params, options)
+
+	var result MessageList
+
+	if err := client.getJSON(ctx, "/api/messages/list", params, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// SearchMessages retrieves a page of the list of messages satisfying search query.
+//
+// Searches all mailboxes when mailboxID is empty. Messages are listed newest first by default.
+func (client *Client) SearchMessages(
+	ctx context.Context,
+	query string,
+	mailboxID string,
+	options ListOptions,
+) (*MessageList, error) {
+	params := url.Values{"query": {query}}
+
+	if mailboxID != "" {
+		params.Set("mailbox_id", mailboxID)
+	}
+
+	addListOptions(params, options)
+
+	var result MessageList
+
+	if err := client.getJSON(ctx, "/api/messages/search", params, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetMessage retrieves full information on stored message.
+//
+// Returns ErrNotFound for unknown message.
+func (client *Client) GetMessage(ctx context.Context, messageID string) (*MessageDetails, error) {
+	var result MessageDetails
+
+	if err := client.getJSON(ctx, "/api/messages/details", url.Values{"message_id": {messageID}}, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// WaitMessage awaits a message satisfying filter to be stored into mailbox, or into any mailbox when mailboxID is empty.
+//
+// When matching message is already stored, returns the oldest one right away. Zero timeout stands for server default
+// (30 seconds). Returns ErrNoMessage when no matching message has been stored before timeout elapsed.
+func (client *Client) WaitMessage(
+	ctx context.Context,
+	mailboxID string,
+	filter Filter,
+	timeout time.Duration,
+) (*MessageDetails, error) {
+	params := url.Values{}
+
+	if mailboxID != "" {
+		params.Set("mailbox_id", mailboxID)
+	}
+
+	if timeout > 0 {
+		params.Set("timeout", timeout.String())
+	}
+
+	addFilter(params, filter)
+
+	response, err := client.do(ctx, http.MethodGet, "/api/messages/wait", params, nil, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNoContent {
+		return nil, ErrNoMessage
+	}
+
+	var result MessageDetails
+
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("cannot decode awaited message: %w", err)
+	}
+
+	return &result, nil
+}
+
+// DeleteMessage deletes stored message.
+//
+// Returns ErrNotFound for unknown message.
+func (client *Client) DeleteMessage(ctx context.Context, messageID string) error {
+	return client.post(ctx, "/api/messages/delete", url.Values{"message_id": {messageID}})
+}
+
+// GetAttachment retrieves decoded contents of message attachment by its zero-based index.
+//
+// Returns ErrNotFound for unknown message or attachment.
+func (client *Client) GetAttachment(ctx context.Context, messageID string, index int) ([]byte, error) {
+	params := url.Values{"message_id": {messageID}, "index": {strconv.Itoa(index)}}
+
+	return client.getBytes(ctx, "/api/messages/attachment", params)
+}
+
+// GetMessageHtml retrieves HTML content of message, with references to inline attachments pointing at attachment
+// download API.
+//
+// Returns ErrNotFound for unknown message or message without HTML content.
+func (client *Client) GetMessageHtml(ctx context.Context, messageID string) (string, error) {
+	data, err := client.getBytes(ctx, "/api/messages/html", url.Values{"message_id": {messageID}})
+
+	return string(data), err
+}
+
+// AddMessage stores raw RFC 5322 message into mailbox and returns ID of the message stored.
+//
+// Mailbox is registered if necessary. Returns ErrBadRequest for empty message.
+func (client *Client) AddMessage(ctx context.Context, mailboxID string, rawMessage string) (string, error) {
+	return client.addMessage(ctx, mailboxID, strings.NewReader(rawMessage), "message/rfc822")
+}
+
+// ComposeMessage composes MIME message out of its description, stores it into mailbox and returns ID of the message
+// stored.
+//
+// Mailbox is registered if necessary. Returns ErrBadRequest for malformed addresses.
+func (client *Client) ComposeMessage(ctx context.Context, mailboxID string, msg *NewMessage) (string, error) {
+	encoded, err := json.Marshal(msg)
+
+	if err != nil {
+		return "", err
+	}
+
+	return client.addMessage(ctx, mailboxID, bytes.NewReader(encoded), "application/json")
+}
+
+// addMessage sends message to be stored to message injection API and returns ID of the message stored.
+func (client *Client) addMessage(ctx context.Context, mailboxID string, body io.Reader, contentType string) (string, error) {
+	response, err := client.do(ctx, http.MethodPost, "/api/messages", url.Values{"mailbox_id": {mailboxID}}, body, contentType)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer response.Body.Close()
+
+	var result struct {
+		ID string `json:"id"`
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("cannot decode stored message ID: %w", err)
+	}
+
+	return result.ID, nil
+}
+
+// ReleaseMessage relays stored message as is to recipients via upstream SMTP relay configured on the server.
+//
+// Returns ErrNotFound for unknown message and when release is disabled, and ErrBadRequest for malformed recipients.
+// Recipients outside of allowed domains are rejected with HTTP 403 Forbidden, and failures of upstream relay with
+// HTTP 502 Bad Gateway, both returned as StatusError.
+func (client *Client) ReleaseMessage(ctx context.Context, messageID string, recipients []string) error {
+	return client.post(ctx, "/api/messages/release", url.Values{"message_id": {messageID}, "recipient": recipients})
+}
@@ -0,0 +1,210 @@
+package client
+
+import "time"
+
+// Order describes sort order of a list.
+type Order string
+
+const (
+	// OrderNewest sorts items newest first. Default for message lists.
+	OrderNewest Order = "newest"
+
+	// OrderOldest sorts items oldest first. Default for mailbox lists.
+	OrderOldest Order = "oldest"
+
+	// OrderSubject sorts messages by decoded subject alphabetically, case-insensitive.
+	OrderSubject Order = "subject"
+
+	// OrderSize sorts messages by size, largest first.
+	OrderSize Order = "size"
+
+	// OrderID sorts mailboxes by ID alphabetically.
+	OrderID Order = "id"
+)
+
+// ListOptions describes which page of a list to retrieve. Zero value requests the whole list in default order.
+type ListOptions struct {
+	// Sort contains sort order. Empty string stands for default order of the list.
+	Sort Order
+
+	// Limit contains maximum number of items per page. Zero stands for no limit.
+	Limit int
+
+	// Cursor contains cursor of the page as returned along with the previous one. Empty string requests the first page.
+	Cursor string
+}
+
+// Filter describes conditions a message is to satisfy. Empty conditions are satisfied by any message.
+type Filter struct {
+	// Recipient contains address any of envelope recipients or To header addresses must contain. Case-insensitive.
+	Recipient string
+
+	// From contains address envelope return path or any of From header addresses must contain. Case-insensitive.
+	From string
+
+	// Subject contains regular expression decoded message subject must match.
+	Subject string
+
+	// After contains time message must be received at or after. Precision is one second.
+	After time.Time
+}
+
+// Mailbox describes individual mailbox.
+type Mailbox struct {
+	ID string `json:"id"`
+}
+
+// MailboxList describes a page of mailbox list.
+type MailboxList struct {
+	// Total contains number of mailboxes in the whole list.
+	Total int `json:"total"`
+
+	// NextCursor contains cursor of the next page. nil for the last page.
+	NextCursor *string `json:"nextCursor"`
+
+	Mailboxes []Mailbox `json:"mailboxes"`
+}
+
+// Message describes essential information on individual message, as listed by HTTP API.
+type Message struct {
+	ID         string     `json:"id"`
+	From       []string   `json:"from"`
+	To         []string   `json:"to"`
+	Subject    string     `json:"subject"`
+	RawHeaders RawHeaders `json:"rawHeaders"`
+
+	// ReceivedAt contains Unix timestamp message has been received at.
+	ReceivedAt int64 `json:"receivedAt"`
+
+	// Envelope contains SMTP envelope. nil when message has not been received via SMTP.
+	Envelope *Envelope `json:"envelope"`
+
+	Size           int    `json:"size"`
+	HasAttachments bool   `json:"hasAttachments"`
+	Snippet        string `json:"snippet"`
+
+	// Error contains parse error description. nil when message has been parsed successfully.
+	Error *string `json:"error"`
+}
+
+// MessageList describes a page of message list.
+type MessageList struct {
+	// Total contains number of messages in the whole list.
+	Total int `json:"total"`
+
+	// NextCursor contains cursor of the next page. nil for the last page.
+	NextCursor *string `json:"nextCursor"`
+
+	Messages []Message `json:"messages"`
+}
+
+// MessageDetails describes full information on individual message.
+type MessageDetails struct {
+	ID         string     `json:"id"`
+	From       []string   `json:"from"`
+	To         []string   `json:"to"`
+	Subject    string     `json:"subject"`
+	RawHeaders RawHeaders `json:"rawHeaders"`
+
+	// ReceivedAt contains Unix timestamp message has been received at.
+	ReceivedAt int64 `json:"receivedAt"`
+
+	// Envelope contains SMTP envelope. nil when message has not been received via SMTP.
+	Envelope *Envelope `json:"envelope"`
+
+	Content     Content      `json:"content"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+// RawHeaders describes raw values of message headers found in decoded form elsewhere.
+type RawHeaders struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+}
+
+// Content describes message contents.
+type Content struct {
+	// Raw contains raw message contents along with headers and body.
+	Raw string `json:"raw"`
+
+	// Html contains HTML content. nil when message has no HTML content.
+	Html        *string `json:"html"`
+	HtmlCharset string  `json:"htmlCharset"`
+
+	// Text contains plain-text content. nil when message has no plain-text content.
+	Text        *string `json:"text"`
+	TextCharset string  `json:"textCharset"`
+}
+
+// Attachment describes message attachment.
+type Attachment struct {
+	Index       int    `json:"index"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	ContentID   string `json:"contentId"`
+	Disposition string `json:"disposition"`
+	Size        int    `json:"size"`
+
+	// URL contains path of attachment download API serving attachment contents, relative to API base URL.
+	URL string `json:"url"`
+}
+
+// Envelope describes SMTP envelope the message has been delivered with.
+type Envelope struct {
+	ReturnPath    string      `json:"returnPath"`
+	Recipients    []Recipient `json:"recipients"`
+	Helo          string      `json:"helo"`
+	ClientIP      string      `json:"clientIp"`
+	TLS           bool        `json:"tls"`
+	AuthUsername  string      `json:"authUsername"`
+	Size          int64       `json:"size"`
+	Body          string      `json:"body"`
+	SmtpUtf8      bool        `json:"smtpUtf8"`
+	RequireTLS    bool        `json:"requireTls"`
+	DsnReturn     string      `json:"dsnReturn"`
+	DsnEnvelopeID string      `json:"dsnEnvelopeId"`
+	MailAuth      *string     `json:"mailAuth"`
+}
+
+// Recipient describes individual envelope recipient.
+type Recipient struct {
+	Address                  string   `json:"address"`
+	DsnNotify                []string `json:"dsnNotify"`
+	DsnOriginalRecipient     string   `json:"dsnOriginalRecipient"`
+	DsnOriginalRecipientType string   `json:"dsnOriginalRecipientType"`
+}
+
+// NewMessage describes structured message to be composed and stored.
+type NewMessage struct {
+	From        string          `json:"from,omitempty"`
+	To          []string        `json:"to,omitempty"`
+	Cc          []string        `json:"cc,omitempty"`
+	Subject     string          `json:"subject,omitempty"`
+	Text        string          `json:"text,omitempty"`
+	Html        string          `json:"html,omitempty"`
+	Attachments []NewAttachment `json:"attachments,omitempty"`
+}
+
+// NewAttachment describes attachment of a message to be composed.
+type NewAttachment struct {
+	Filename string `json:"filename"`
+
+	// ContentType contains attachment media type. Guessed by file name extension when empty.
+	ContentType string `json:"contentType,omitempty"`
+
+	Data []byte `json:"data"`
+}
+
+// Event describes individual storage change.
+type Event struct {
+	// Type contains kind of the change: "mailbox.added", "mailbox.deleted", "message.added" or "message.deleted".
+	//
+	// Type is "resync" instead, with no mailbox, once events have been dropped, so that the state should be reloaded.
+	Type string `json:"type"`
+
+	MailboxID string `json:"mailboxId"`
+
+	// MessageID contains ID of the message affected. Empty for mailbox events.
+	MessageID string `json:"messageId"`
+}
@@ -1,8 +1,13 @@
 module zinktray
 
-go 1.25
+go 1.25.0
 
 require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/emersion/go-imap/v2 v2.0.0-beta.8
+	github.com/emersion/go-message v0.18.2
 	github.com/emersion/go-sasl v0.0.0-20241020182733-b788ff22d5a6
 	github.com/emersion/go-smtp v0.24.0
+	golang.org/x/text v0.40.0
+	gopkg.in/yaml.v3 v3.0.1
 )
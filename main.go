@@ -2,19 +2,183 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
 	"zinktray/app"
 	"zinktray/app/api"
+	"zinktray/app/certificate"
+	"zinktray/app/cli"
+	"zinktray/app/config"
+	"zinktray/app/imap"
+	"zinktray/app/metrics"
+	"zinktray/app/pop3"
+	"zinktray/app/relay"
+	"zinktray/app/retention"
+	"zinktray/app/sendmail"
 	"zinktray/app/smtp"
 	"zinktray/app/storage"
+	"zinktray/app/webhook"
 )
 
+// serveCommand contains name of the subcommand starting the server, which is also run when no subcommand is given.
+const serveCommand = "serve"
+
 func main() {
-	store := storage.NewStorage()
+	if args, ok := sendmailArgs(os.Args); ok {
+		runSendmail(args)
+
+		return
+	}
+
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") {
+		runServer(os.Args[1:])
+
+		return
+	}
+
+	switch name := os.Args[1]; name {
+	case serveCommand:
+		runServer(os.Args[2:])
+	case "help":
+		usage(os.Stdout)
+	default:
+		command := cli.Find(name)
+
+		if command == nil {
+			fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+			usage(os.Stderr)
+			os.Exit(2)
+		}
+
+		runCommand(command, os.Args[2:])
+	}
+}
+
+// usage writes list of available subcommands.
+func usage(output io.Writer) {
+	fmt.Fprintln(output, "Usage: zinktray [serve] [flags]")
+	fmt.Fprintln(output, "       zinktray sendmail [options] [recipients]")
+	fmt.Fprintln(output, "       zinktray <command> [flags] [args]")
+	fmt.Fprintln(output)
+	fmt.Fprintln(output, "Commands talking to a running server:")
+
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+
+	for _, command := range cli.Commands {
+		fmt.Fprintf(writer, "  %s %s\t%s\n", command.Name, command.Args, command.Description)
+	}
+
+	writer.Flush()
+
+	fmt.Fprintln(output)
+	fmt.Fprintln(output, "Run \"zinktray <command> -help\" for command flags.")
+}
+
+// runServer starts the server configured by command-line arguments, and awaits its termination.
+func runServer(args []string) {
+	cfg, err := config.Load(os.Args[0], args, os.Stderr)
+
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	} else if err != nil {
+		log.Fatalf("Cannot load configuration: %s", err)
+	}
+
+	var bundle *certificate.Bundle
+
+	if cfg.UsesTLS() {
+		if bundle, err = certificate.New(cfg.TLS); err != nil {
+			log.Fatalf("Cannot prepare TLS certificate: %s", err)
+		}
+	}
+
+	store, err := storage.New(cfg.Storage)
 
-	smtpServer := smtp.NewServer(store)
-	apiServer := api.NewServer(store)
+	if err != nil {
+		log.Fatalf("Cannot initialize storage: %s", err)
+	}
 
-	application := app.NewApp(smtpServer, apiServer)
+	collector := metrics.NewMetrics()
+
+	smtpServer := smtp.NewServer(store, cfg.SMTP, bundle, collector)
+	pop3Server := pop3.NewServer(store, cfg.POP3, bundle)
+	imapServer := imap.NewServer(store, cfg.IMAP, bundle)
+	apiServer := api.NewServer(store, cfg.API, bundle, relay.NewRelay(cfg.Relay), collector)
+	janitor := retention.NewJanitor(store, cfg.Retention)
+	notifier := webhook.NewNotifier(store, cfg.Webhooks)
+
+	application := app.NewApp(smtpServer, pop3Server, imapServer, apiServer, janitor, notifier)
 
 	application.Start(context.Background())
 }
+
+// runCommand executes command talking to a running server through HTTP API.
+//
+// HTTP API address is taken from configuration file and environment variables, and may be overridden with "-url" flag.
+func runCommand(command *cli.Command, args []string) {
+	cfg, err := config.LoadClient(command.Name)
+
+	if err != nil {
+		log.Fatalf("Cannot load configuration: %s", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	defer cancel()
+
+	err = command.Run(ctx, cli.BaseURL(cfg.API.Addr), args, os.Stdout, os.Stderr)
+
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	} else if err != nil {
+		log.Fatalf("Command %s failed: %s", command.Name, err)
+	}
+}
+
+// sendmailArgs tells whether sendmail-compatible command is invoked, either as "sendmail" subcommand or via symlink
+// named sendmail, and returns its arguments.
+func sendmailArgs(args []string) ([]string, bool) {
+	if filepath.Base(args[0]) == sendmail.CommandName {
+		return args[1:], true
+	}
+
+	if len(args) > 1 && args[1] == sendmail.CommandName {
+		return args[2:], true
+	}
+
+	return nil, false
+}
+
+// runSendmail submits message read from standard input to a running server.
+//
+// Submission is configured by configuration file and environment variables only, as arguments follow sendmail syntax.
+// Exits with sysexits.h status upon failure, so that callers could tell temporary failures from permanent ones.
+func runSendmail(args []string) {
+	opts, err := sendmail.ParseArgs(args)
+
+	if err != nil {
+		log.Printf("Invalid arguments: %s", err)
+		os.Exit(sendmail.ExitUsage)
+	}
+
+	cfg, err := config.LoadSendmail(sendmail.CommandName)
+
+	if err != nil {
+		log.Printf("Cannot load configuration: %s", err)
+		os.Exit(sendmail.ExitConfig)
+	}
+
+	if err := sendmail.Send(cfg.Sendmail, opts, os.Stdin); err != nil {
+		log.Printf("Cannot send message: %s", err)
+		os.Exit(sendmail.ExitStatus(err))
+	}
+}
@@ -0,0 +1,196 @@
+package ztest
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+	"zinktray/app/api"
+	"zinktray/app/certificate"
+	"zinktray/app/config"
+	"zinktray/app/message"
+	"zinktray/app/message/filter"
+	"zinktray/app/metrics"
+	"zinktray/app/relay"
+	"zinktray/app/smtp"
+	"zinktray/app/storage"
+)
+
+// DefaultTimeout contains time WaitForMessage awaits a message for by default.
+const DefaultTimeout = 5 * time.Second
+
+// Server structure represents zinktray instance started for a test.
+type Server struct {
+	// SMTPAddr contains address SMTP server listens on (e.g. "127.0.0.1:41234").
+	SMTPAddr string
+
+	// APIURL contains base URL of HTTP API and web inbox UI (e.g. "http://127.0.0.1:41235").
+	APIURL string
+
+	// Username contains SMTP username to authenticate with. With default routing, messages are stored into the mailbox
+	// named after the username.
+	Username string
+
+	// Password contains SMTP password to authenticate with. Any password is accepted.
+	Password string
+
+	// Timeout contains time WaitForMessage awaits a message for.
+	Timeout time.Duration
+
+	// Store contains storage messages are stored into.
+	Store storage.Storage
+}
+
+// Start starts SMTP server and HTTP API server with default configuration on ephemeral ports.
+//
+// Servers are terminated upon test completion.
+func Start(t testing.TB) *Server {
+	t.Helper()
+
+	return StartWithConfig(t, config.Default())
+}
+
+// StartWithConfig starts SMTP server and HTTP API server with provided configuration on ephemeral ports.
+//
+// Listen addresses and storage configuration are ignored: servers listen on loopback interface and messages are kept in
+// memory, so that every server is isolated. Servers are terminated upon test completion.
+func StartWithConfig(t testing.TB, cfg *config.Config) *Server {
+	t.Helper()
+
+	var bundle *certificate.Bundle
+
+	if cfg.SMTP.StartTLS {
+		var err error
+
+		if bundle, err = certificate.New(cfg.TLS); err != nil {
+			t.Fatalf("Cannot prepare TLS certificate: %s", err)
+		}
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("Cannot listen for SMTP connections: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	waitGroup := &sync.WaitGroup{}
+	store := storage.NewMemoryStorage()
+	collector := metrics.NewMetrics()
+
+	waitGroup.Add(1)
+
+	go func() {
+		defer waitGroup.Done()
+
+		smtp.NewServer(store, cfg.SMTP, bundle, collector).Serve(ctx, listener)
+	}()
+
+	handler := api.NewServer(store, cfg.API, bundle, relay.NewRelay(cfg.Relay), collector).Handler()
+	apiServer := httptest.NewUnstartedServer(handler)
+
+	// Long-living requests (e.g. event streams) are cancelled as soon as the server is going to terminate.
+	apiServer.Config.BaseContext = func(_ net.Listener) context.Context {
+		return ctx
+	}
+
+	apiServer.Start()
+
+	t.Cleanup(func() {
+		cancel()
+		waitGroup.Wait()
+		apiServer.Close()
+	})
+
+	return &Server{
+		SMTPAddr: listener.Addr().String(),
+		APIURL:   apiServer.URL,
+		Username: "ztest",
+		Password: "ztest",
+		Timeout:  DefaultTimeout,
+		Store:    store,
+	}
+}
+
+// Messages returns every stored message, oldest first.
+func (srv *Server) Messages() []*message.Message {
+	messages := make([]*message.Message, 0)
+
+	for _, mbx := range srv.Store.GetMailboxes() {
+		messages = append(messages, srv.Store.GetMessages(mbx.ID)...)
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].ReceivedAt.Before(messages[j].ReceivedAt)
+	})
+
+	return messages
+}
+
+// WaitForMessage awaits a message matching messageFilter stored into any mailbox and returns it.
+//
+// When matching message is already stored, returns the oldest one right away. nil filter is satisfied by any message.
+// Fails the test when no matching message is stored within Timeout.
+func (srv *Server) WaitForMessage(t testing.TB, messageFilter *filter.Filter) *message.Message {
+	t.Helper()
+
+	if messageFilter == nil {
+		messageFilter = &filter.Filter{}
+	}
+
+	// Subscribe before looking through stored messages, so that no message is missed in between.
+	subscription := srv.Store.Subscribe(64)
+
+	defer subscription.Close()
+
+	if msg := srv.findMessage(messageFilter); msg != nil {
+		return msg
+	}
+
+	timer := time.NewTimer(srv.Timeout)
+
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			t.Fatalf("No matching message has been stored within %s", srv.Timeout)
+
+			return nil
+		case event := <-subscription.Events:
+			if event.Type == storage.EventMessageAdded && messageFilter.Match(event.Message) {
+				return event.Message
+			}
+		case <-subscription.Dropped:
+			// Matching message might have been among dropped events.
+			if msg := srv.findMessage(messageFilter); msg != nil {
+				return msg
+			}
+		}
+	}
+}
+
+// findMessage returns the oldest stored message matching messageFilter, or nil when no message matches.
+func (srv *Server) findMessage(messageFilter *filter.Filter) *message.Message {
+	for _, msg := range srv.Messages() {
+		if messageFilter.Match(msg) {
+			return msg
+		}
+	}
+
+	return nil
+}
+
+// AssertNoMail fails the test when any message is stored.
+//
+// Messages submitted over SMTP are stored before submission is acknowledged, so that no waiting is necessary.
+func (srv *Server) AssertNoMail(t testing.TB) {
+	t.Helper()
+
+	if messages := srv.Messages(); len(messages) > 0 {
+		t.Errorf("No message is expected to be stored, got %d, the first one is \"%s\"", len(messages), messages[0].ID)
+	}
+}
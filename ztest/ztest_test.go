@@ -0,0 +1,97 @@
+package ztest
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"zinktray/app/message/filter"
+
+	"github.com/emersion/go-sasl"
+	"github.com/emersion/go-smtp"
+)
+
+func TestStart(t *testing.T) {
+	var srv = Start(t)
+
+	srv.AssertNoMail(t)
+
+	// Every server is isolated, so that another one does not interfere.
+	var other = Start(t)
+
+	if other.SMTPAddr == srv.SMTPAddr || other.APIURL == srv.APIURL {
+		t.Fatal("Servers are expected to listen on different ports")
+	}
+
+	var sent = make(chan struct{})
+
+	go func() {
+		defer close(sent)
+
+		send(t, srv, "Subject: Welcome\r\n\r\nHello\r\n")
+	}()
+
+	var msg = srv.WaitForMessage(t, &filter.Filter{Subject: regexp.MustCompile("^Welcome$")})
+
+	<-sent
+
+	if subject := msg.GetSummary().Subject; subject != "Welcome" {
+		t.Errorf("Unexpected subject: %s", subject)
+	}
+
+	if msg.Envelope == nil || msg.Envelope.AuthUsername != srv.Username {
+		t.Errorf("Unexpected envelope: %+v", msg.Envelope)
+	}
+
+	if again := srv.WaitForMessage(t, nil); again.ID != msg.ID {
+		t.Errorf("Stored message is expected to be returned right away, got \"%s\"", again.ID)
+	}
+
+	other.AssertNoMail(t)
+
+	response, err := http.Get(srv.APIURL + "/api/mailboxes/list")
+
+	if err != nil {
+		t.Fatalf("Cannot list mailboxes: %s", err)
+	}
+
+	defer response.Body.Close()
+
+	var mailboxes struct {
+		Mailboxes []struct {
+			ID string `json:"id"`
+		} `json:"mailboxes"`
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&mailboxes); err != nil {
+		t.Fatalf("Cannot decode mailbox list: %s", err)
+	}
+
+	if len(mailboxes.Mailboxes) != 1 || mailboxes.Mailboxes[0].ID != srv.Username {
+		t.Errorf("Unexpected mailboxes: %+v", mailboxes.Mailboxes)
+	}
+}
+
+// send submits message to the server authenticated with server credentials.
+func send(t *testing.T, srv *Server, rawMessage string) {
+	var client, err = smtp.Dial(srv.SMTPAddr)
+
+	if err != nil {
+		t.Errorf("Cannot connect to SMTP server: %s", err)
+
+		return
+	}
+
+	defer client.Close()
+
+	if err := client.Auth(sasl.NewPlainClient("", srv.Username, srv.Password)); err != nil {
+		t.Errorf("Cannot authenticate: %s", err)
+
+		return
+	}
+
+	if err := client.SendMail("alice@example.com", []string{"bob@example.com"}, strings.NewReader(rawMessage)); err != nil {
+		t.Errorf("Cannot send message: %s", err)
+	}
+}